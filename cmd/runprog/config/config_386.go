@@ -0,0 +1,36 @@
+package config
+
+// This file includes configs for the run program settings
+
+var (
+	archReadableFiles = []string{
+		"/lib/i386-linux-gnu/",
+		"/usr/lib/i386-linux-gnu/",
+	}
+
+	archSyscallAllows = []string{
+		"fstat64", // 32-bit
+		"_llseek", // 32-bit
+		"fcntl64", // 32-bit
+		"mmap2",   // 32-bit
+		// arch
+		"uname",
+		"ugetrlimit",
+		"set_thread_area",
+		"fadvise64_64",
+		"dup2",
+		"time",
+	}
+
+	archSyscallTraces = []string{
+		"lstat64",   // 32-bit
+		"stat64",    // 32-bit
+		"fstatat64", // 32-bit
+		"open",
+		"unlink",
+		"readlink",
+		"lstat",
+		"stat",
+		"access",
+	}
+)
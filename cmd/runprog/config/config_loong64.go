@@ -0,0 +1,17 @@
+package config
+
+// This file includes configs for the run program settings
+
+var (
+	archReadableFiles = []string{
+		"/lib/loongarch64-linux-gnu/",
+		"/usr/lib/loongarch64-linux-gnu/",
+	}
+
+	archSyscallAllows = []string{}
+
+	archSyscallTraces = []string{
+		"newfstatat",
+		"statx",
+	}
+)
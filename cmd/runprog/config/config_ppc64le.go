@@ -0,0 +1,26 @@
+package config
+
+// This file includes configs for the run program settings
+
+var (
+	archReadableFiles = []string{
+		"/lib/powerpc64le-linux-gnu/",
+		"/usr/lib/powerpc64le-linux-gnu/",
+	}
+
+	archSyscallAllows = []string{
+		"dup2",
+		"time",
+		"uname",
+	}
+
+	archSyscallTraces = []string{
+		"open",
+		"unlink",
+		"readlink",
+		"lstat",
+		"stat",
+		"access",
+		"newfstatat",
+	}
+)
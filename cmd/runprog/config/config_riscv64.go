@@ -0,0 +1,19 @@
+package config
+
+// This file includes configs for the run program settings
+
+var (
+	archReadableFiles = []string{
+		"/lib/riscv64-linux-gnu/",
+		"/usr/lib/riscv64-linux-gnu/",
+	}
+
+	archSyscallAllows = []string{
+		"riscv_flush_icache",
+	}
+
+	archSyscallTraces = []string{
+		"newfstatat",
+		"statx",
+	}
+)
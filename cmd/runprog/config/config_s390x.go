@@ -0,0 +1,25 @@
+package config
+
+// This file includes configs for the run program settings
+
+var (
+	archReadableFiles = []string{
+		"/lib/s390x-linux-gnu/",
+		"/usr/lib/s390x-linux-gnu/",
+	}
+
+	archSyscallAllows = []string{
+		"dup2",
+		"uname",
+	}
+
+	archSyscallTraces = []string{
+		"open",
+		"unlink",
+		"readlink",
+		"lstat",
+		"stat",
+		"access",
+		"newfstatat",
+	}
+)
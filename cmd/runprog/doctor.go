@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/criyle/go-sandbox/pkg/probe"
+)
+
+const doctorCmd = "doctor"
+
+// doctor prints the kernel features available to the sandbox together with
+// the recommended runner
+func doctor() {
+	if _, err := probe.Probe().WriteTo(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// explain prints the missing kernel feature that likely caused one of errs
+func explain(errs ...error) {
+	r := probe.Probe()
+	for _, err := range errs {
+		if s := r.Explain(err); s != "" {
+			fmt.Fprintf(os.Stderr, "%s (run \"%s %s\" for details)\n", s, os.Args[0], doctorCmd)
+			return
+		}
+	}
+}
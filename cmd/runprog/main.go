@@ -14,6 +14,7 @@ const (
 
 func printUsage() {
 	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] <args>\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "       %s %s (print kernel features and the recommended runner)\n", os.Args[0], doctorCmd)
 	flag.PrintDefaults()
 	os.Exit(2)
 }
@@ -39,7 +40,7 @@ func getStatus(s runner.Status) int {
 		return int(StatusNormal)
 	case runner.StatusInvalid:
 		return int(StatusInvalid)
-	case runner.StatusTimeLimitExceeded:
+	case runner.StatusTimeLimitExceeded, runner.StatusRealTimeLimitExceeded:
 		return int(StatusTLE)
 	case runner.StatusMemoryLimitExceeded:
 		return int(StatusMLE)
@@ -47,7 +48,7 @@ func getStatus(s runner.Status) int {
 		return int(StatusOLE)
 	case runner.StatusDisallowedSyscall:
 		return int(StatusBan)
-	case runner.StatusSignalled, runner.StatusNonzeroExitStatus:
+	case runner.StatusSignalled, runner.StatusNonzeroExitStatus, runner.StatusProcessLimitExceeded:
 		return int(StatusRE)
 	default:
 		return int(StatusFatal)
@@ -25,6 +25,10 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == doctorCmd {
+		doctor()
+		return
+	}
 	flag.Usage = printUsage
 	flag.Uint64Var(&timeLimit, "tl", 1, "Set time limit (in second)")
 	flag.Uint64Var(&realTimeLimit, "rtl", 0, "Set real time limit (in second)")
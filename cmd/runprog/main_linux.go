@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/signal"
 	"sync/atomic"
@@ -33,11 +34,17 @@ var (
 	addReadable, addWritable, addRawReadable, addRawWritable       arrayFlags
 	allowProc, unsafe, showDetails, useCGroup, memfile, cred, nucg bool
 	timeLimit, realTimeLimit, memoryLimit, outputLimit, stackLimit uint64
+	maxProcesses, maxTotalProcesses, maxThreads                    uint64
 	inputFileName, outputFileName, errorFileName, workPath, runt   string
 
-	useCGroupFd   bool
-	pType, result string
-	args          []string
+	useCGroupFd    bool
+	sampleInterval time.Duration
+	traceSummary   bool
+	audit          bool
+	auditBan       bool
+	traceJSON      string
+	pType, result  string
+	args           []string
 )
 
 // container init
@@ -46,6 +53,10 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == doctorCmd {
+		doctor()
+		return
+	}
 	flag.Usage = printUsage
 	flag.Uint64Var(&timeLimit, "tl", 1, "Set time limit (in second)")
 	flag.Uint64Var(&realTimeLimit, "rtl", 0, "Set real time limit (in second)")
@@ -63,6 +74,9 @@ func main() {
 	flag.BoolVar(&unsafe, "unsafe", false, "Don't check dangerous syscalls")
 	flag.BoolVar(&showDetails, "show-trace-details", false, "Show trace details")
 	flag.BoolVar(&allowProc, "allow-proc", false, "Allow fork, exec... etc.")
+	flag.Uint64Var(&maxProcesses, "max-proc", 0, "Set the concurrent processes limit (cgroup pids.max for ns and container runner, 0 for unlimited)")
+	flag.Uint64Var(&maxTotalProcesses, "max-total-proc", 0, "Set the total processes limit for ptrace runner (0 for unlimited)")
+	flag.Uint64Var(&maxThreads, "max-thread", 0, "Set the concurrent threads limit for ptrace runner (0 for unlimited)")
 	flag.Var(&addRawReadable, "add-readable-raw", "Add a readable file (don't transform to its real path)")
 	flag.Var(&addRawWritable, "add-writable-raw", "Add a writable file (don't transform to its real path)")
 	flag.BoolVar(&useCGroup, "cgroup", false, "Use cgroup to colloct resource usage")
@@ -71,6 +85,11 @@ func main() {
 	flag.StringVar(&runt, "runner", "ptrace", "Runner for the program (ptrace, ns, container)")
 	flag.BoolVar(&cred, "cred", false, "Generate credential for containers (uid=10000)")
 	flag.BoolVar(&nucg, "nucg", false, "don't unshare cgroup")
+	flag.BoolVar(&audit, "audit", false, "Record file access violations of ptrace runner instead of killing the program")
+	flag.BoolVar(&auditBan, "audit-ban", false, "Soft ban the violations recorded by -audit instead of allowing them")
+	flag.BoolVar(&traceSummary, "trace-summary", false, "Print the traced syscalls summary table of ptrace runner to stderr")
+	flag.StringVar(&traceJSON, "trace-json", "", "Record the traced syscalls of ptrace runner as JSON lines into the file")
+	flag.DurationVar(&sampleInterval, "sample", 0, "Sample resource usage at the interval to enforce limits during the run (e.g. 10ms)")
 	flag.Parse()
 
 	args = flag.Args()
@@ -122,6 +141,9 @@ func main() {
 		if !ok {
 			c = runner.StatusRunnerError
 		}
+		if c == runner.StatusRunnerError {
+			explain(err, errors.New(rt.Error))
+		}
 		// Handle fatal error from trace
 		fmt.Fprintf(f, "%d %d %d %d\n", getStatus(c),
 			int(rt.Time.Round(time.Millisecond)/time.Millisecond), uint64(rt.Memory)>>10, rt.ExitStatus)
@@ -152,6 +174,9 @@ func start() (*runner.Result, error) {
 		err      error
 		execFile uintptr
 		rt       runner.Result
+		summary  *ptrace.Summary
+		jsonRec  *ptrace.JSONRecorder
+		pressure *cgroup.PressureSampler
 	)
 
 	addRead := filehandler.GetExtraSet(addReadable, addRawReadable)
@@ -210,7 +235,26 @@ func start() (*runner.Result, error) {
 		if err = cg.SetMemoryLimit(memoryLimit << 20); err != nil {
 			return nil, err
 		}
+		// avoid exceeding memory limit by swapping, swap accounting may not be enabled
+		// (cgroupfs denies to create the file if it does not exist)
+		if err = cg.SetMemorySwapMax(0); err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission) {
+			return nil, err
+		}
+		// only the ptrace runner counts processes itself
+		if runt != "ptrace" && maxProcesses > 0 {
+			if err = cg.SetProcLimit(maxProcesses); err != nil {
+				return nil, err
+			}
+		}
 		debug("cgroup:", cg)
+		// pressure stall information only exists in cgroup v2
+		if t == cgroup.TypeV2 {
+			interval := sampleInterval
+			if interval <= 0 {
+				interval = 100 * time.Millisecond
+			}
+			pressure = cgroup.NewPressureSampler(cg, interval)
+		}
 		if useCGroupFd {
 			debug("use cgroup fd")
 			if t != cgroup.TypeV2 {
@@ -300,8 +344,36 @@ func start() (*runner.Result, error) {
 	}
 
 	limit := runner.Limit{
-		TimeLimit:   time.Duration(timeLimit) * time.Second,
-		MemoryLimit: runner.Size(memoryLimit << 20),
+		TimeLimit:     time.Duration(timeLimit) * time.Second,
+		MemoryLimit:   runner.Size(memoryLimit << 20),
+		WallTimeLimit: time.Duration(realTimeLimit) * time.Second,
+		OutputLimit:   runner.Size(outputLimit << 20),
+	}
+	if runt == "ptrace" {
+		limit.ProcessLimit = maxProcesses
+	} else if maxProcesses > 0 && cg == nil {
+		return nil, fmt.Errorf("max-proc requires cgroup for runner %s", runt)
+	}
+
+	var sampler *runner.Sampler
+	if sampleInterval > 0 {
+		sampler = &runner.Sampler{
+			Interval:   sampleInterval,
+			MaxSamples: 256,
+		}
+		if cg != nil {
+			sampler.Usage = func(int) (time.Duration, runner.Size, error) {
+				cpu, err := cg.CPUUsage()
+				if err != nil {
+					return 0, 0, err
+				}
+				memory, err := cg.MemoryUsage()
+				if err != nil {
+					return 0, 0, err
+				}
+				return time.Duration(cpu), runner.Size(memory), nil
+			}
+		}
 	}
 
 	if runt == "container" {
@@ -309,9 +381,13 @@ func start() (*runner.Result, error) {
 		if cred {
 			credG = newCredGen()
 		}
-		var stderr io.Writer
+		var (
+			stderr io.Writer
+			logger *slog.Logger
+		)
 		if showDetails {
 			stderr = os.Stderr
+			logger = runner.Logger(nil, true)
 		}
 
 		cloneFlag := forkexec.UnshareFlags
@@ -323,6 +399,7 @@ func start() (*runner.Result, error) {
 			TmpRoot:       "dm",
 			Mounts:        mb.Mounts,
 			Stderr:        stderr,
+			Logger:        logger,
 			CredGenerator: credG,
 			CloneFlags:    uintptr(cloneFlag),
 		}
@@ -348,9 +425,11 @@ func start() (*runner.Result, error) {
 				ExecFile:      execFile,
 				RLimits:       rlims.PrepareRLimit(),
 				Seccomp:       filter,
+				Limit:         limit,
 				SyncFunc:      syncFunc,
 				CgroupFD:      cgroupFd,
 				SyncAfterExec: cg == nil || cgDir != nil,
+				Sampler:       sampler,
 			},
 		}
 	} else if runt == "ns" {
@@ -367,6 +446,7 @@ func start() (*runner.Result, error) {
 			Files:       fds,
 			RLimits:     rlims.PrepareRLimit(),
 			Limit:       limit,
+			Sampler:     sampler,
 			Seccomp:     filter,
 			Root:        root,
 			Mounts:      mt,
@@ -376,6 +456,17 @@ func start() (*runner.Result, error) {
 			DomainName:  "run_program",
 		}
 	} else if runt == "ptrace" {
+		summary = new(ptrace.Summary)
+		recorders := ptrace.Recorders{summary}
+		if traceJSON != "" {
+			jf, err := os.Create(traceJSON)
+			if err != nil {
+				return nil, fmt.Errorf("trace json: %v", err)
+			}
+			defer jf.Close()
+			jsonRec = ptrace.NewJSONRecorder(jf)
+			recorders = append(recorders, jsonRec)
+		}
 		r = &ptrace.Runner{
 			Args:        args,
 			Env:         []string{pathEnv},
@@ -383,12 +474,19 @@ func start() (*runner.Result, error) {
 			WorkDir:     workPath,
 			RLimits:     rlims.PrepareRLimit(),
 			Limit:       limit,
+			Sampler:     sampler,
 			Files:       fds,
 			Seccomp:     filter,
 			ShowDetails: showDetails,
 			Unsafe:      unsafe,
 			Handler:     h,
 			SyncFunc:    syncFunc,
+			Recorder:    recorders,
+			Audit:       audit,
+			AuditBan:    auditBan,
+
+			MaxProcesses: maxTotalProcesses,
+			MaxThreads:   maxThreads,
 		}
 	} else {
 		return nil, fmt.Errorf("invalid runner type: %s", runt)
@@ -400,9 +498,13 @@ func start() (*runner.Result, error) {
 
 	// Run tracer
 	sTime := time.Now()
-	c, cancel := context.WithTimeout(context.Background(), time.Duration(int64(realTimeLimit)*int64(time.Second)))
+	// real time limit is enforced by the runner
+	c, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if pressure != nil {
+		pressure.Start()
+	}
 	s := make(chan runner.Result, 1)
 	go func() {
 		s <- r.Run(c)
@@ -425,6 +527,33 @@ func start() (*runner.Result, error) {
 	}
 
 	debug("results:", rt, err)
+	if rt.ChildSignal != 0 {
+		debug("child signaled:", syscall.Signal(rt.ChildSignal))
+	}
+	if len(rt.Samples) > 0 {
+		last := rt.Samples[len(rt.Samples)-1]
+		debug("samples: ", len(rt.Samples), " last: ", last.Elapsed, " ", last.Time, " ", last.Memory)
+	}
+	if summary != nil {
+		if traceSummary {
+			summary.WriteTo(os.Stderr)
+		}
+		for _, v := range rt.Violations {
+			fmt.Fprintf(os.Stderr, "violation: [pid %d] %s %q = %s (x%d)\n", v.Pid, v.Syscall, v.Path, v.Action, v.Count)
+		}
+		// tell which call was blocked for the disallowed syscall verdict
+		if rt.Status == runner.StatusDisallowedSyscall {
+			for _, e := range summary.Blocked {
+				fmt.Fprintln(os.Stderr, "blocked:", e)
+			}
+		}
+	}
+
+	if jsonRec != nil {
+		if err := jsonRec.Err(); err != nil {
+			return nil, fmt.Errorf("trace json: %v", err)
+		}
+	}
 
 	if useCGroup {
 		cpu, err := cg.CPUUsage()
@@ -446,7 +575,40 @@ func start() (*runner.Result, error) {
 		} else if err == nil {
 			rt.ProcPeak = procPeak
 		}
+		// cpu.stat may not exist if cpu accounting is not available
+		cpuStat, err := cg.CPUStat()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("cgroup cpu stat: %v", err)
+		} else if err == nil {
+			rt.Throttled = cpuStat.ThrottledTime
+			rt.NrThrottled = cpuStat.NrThrottled
+		}
 		debug("cgroup: cpu: ", cpu, " memory: ", memory, " procPeak: ", procPeak)
+		if ioStat, err := cg.IOStat(); err == nil {
+			var rBytes, wBytes uint64
+			for _, s := range ioStat {
+				rBytes += s.RBytes
+				wBytes += s.WBytes
+			}
+			debug("cgroup: io read: ", runner.Size(rBytes), " write: ", runner.Size(wBytes))
+		}
+		// the kernel OOM killer sends SIGKILL when the cgroup memory limit is hit
+		if oom, err := cg.MemoryOOMKills(); err == nil && oom > 0 && rt.Cause == runner.CauseKilled {
+			rt.Status = runner.StatusMemoryLimitExceeded
+			rt.Cause = runner.CauseOOM
+		}
+		debug("cgroup: user: ", cpuStat.User, " system: ", cpuStat.System,
+			" throttled: ", cpuStat.NrThrottled, "/", cpuStat.NrPeriods, " ", cpuStat.ThrottledTime)
+		if rt.NrThrottled > 0 {
+			fmt.Fprintf(os.Stderr, "cgroup: cpu throttled %d periods (%v)\n", rt.NrThrottled, rt.Throttled)
+		}
+		if pressure != nil {
+			stall := cgroup.StallTime(pressure.Stop())
+			rt.CPUStall, rt.MemoryStall, rt.IOStall = stall.CPU, stall.Memory, stall.IO
+			if stall != (cgroup.PressureStall{}) {
+				fmt.Fprintf(os.Stderr, "cgroup: stalled cpu %v memory %v io %v\n", stall.CPU, stall.Memory, stall.IO)
+			}
+		}
 		debug("cgroup:", rt)
 	}
 	if rt.Status == runner.StatusTimeLimitExceeded || rt.Status == runner.StatusNormal {
@@ -456,7 +618,7 @@ func start() (*runner.Result, error) {
 			rt.Status = runner.StatusNormal
 		}
 	}
-	if rt.Status == runner.StatusMemoryLimitExceeded || rt.Status == runner.StatusNormal {
+	if (rt.Status == runner.StatusMemoryLimitExceeded || rt.Status == runner.StatusNormal) && rt.Cause != runner.CauseOOM {
 		if rt.Memory > limit.MemoryLimit {
 			rt.Status = runner.StatusMemoryLimitExceeded
 		} else {
@@ -1,13 +1,21 @@
 package container
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
+	"github.com/criyle/go-sandbox/pkg/mount"
 	"github.com/criyle/go-sandbox/runner"
 )
 
@@ -119,6 +127,34 @@ var tests []testCase = []testCase{
 		},
 		expected: runner.StatusRunnerError,
 	},
+	{
+		name: "WallTimeLimit",
+		param: ExecveParam{
+			Args:  []string{"/bin/sleep", "5"},
+			Env:   []string{"PATH=/bin"},
+			Limit: runner.Limit{WallTimeLimit: 100 * time.Millisecond},
+		},
+		expected: runner.StatusRealTimeLimitExceeded,
+	},
+	{
+		name: "SamplerTimeLimit",
+		param: ExecveParam{
+			Args:    []string{"/bin/sh", "-c", "while :; do :; done"},
+			Env:     []string{"PATH=/bin"},
+			Limit:   runner.Limit{TimeLimit: 100 * time.Millisecond, WallTimeLimit: 5 * time.Second},
+			Sampler: &runner.Sampler{},
+		},
+		expected: runner.StatusTimeLimitExceeded,
+	},
+	{
+		name: "ProcessLimitUnsupported",
+		param: ExecveParam{
+			Args:  []string{"/bin/true"},
+			Env:   []string{"PATH=/bin"},
+			Limit: runner.Limit{ProcessLimit: 4},
+		},
+		expected: runner.StatusRunnerError,
+	},
 }
 
 type credgen struct{}
@@ -185,3 +221,233 @@ func getEnv(t *testing.T, credGen CredGenerator) Environment {
 	})
 	return m
 }
+
+func TestContainerMountTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	builder := &Builder{
+		Root:   tmpDir,
+		Stderr: os.Stderr,
+	}
+	tree, err := builder.PrepareMountTree()
+	if err != nil {
+		t.Skip("mount tree not supported:", err)
+	}
+	t.Cleanup(func() {
+		tree.Destroy()
+	})
+	builder.MountTree = tree
+	for i := 0; i < 2; i++ {
+		m, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			m.Destroy()
+		})
+		r := m.Execve(context.TODO(), successParam)
+		if r.Status != runner.StatusNormal {
+			t.Fatal(r.Status, r.Error, r)
+		}
+		// work dir is writable and not shared between containers
+		f, err := m.Open([]OpenCmd{{Path: "/w/a", Flag: os.O_CREATE | os.O_EXCL | os.O_WRONLY, Perm: 0644}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f[0].Err != nil {
+			t.Fatal(f[0].Err)
+		}
+		f[0].File.Close()
+		// cached root is read-only
+		f, err = m.Open([]OpenCmd{{Path: "/a", Flag: os.O_CREATE | os.O_WRONLY, Perm: 0644}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f[0].Err == nil {
+			f[0].File.Close()
+			t.Fatal("expected root to be read-only")
+		}
+		// the shared tmpfs is read-only at superblock level
+		mi, err := os.ReadFile(fmt.Sprintf("/proc/%d/mountinfo", m.(*container).process.Pid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rootSuperReadOnly(string(mi)) {
+			t.Fatalf("expected read-only superblock of root, got:\n%s", mi)
+		}
+	}
+}
+
+func TestSplitMountTree(t *testing.T) {
+	// the bind beneath the tmpfs is listed before it
+	ms, err := (&mount.Builder{}).
+		WithBind("/bin", "w/bin", true).
+		WithTmpfs("w", "").
+		WithBind("/usr", "usr", true).
+		Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, mounts, mountPoints := splitMountTree(ms)
+	if len(cached) != 1 || cached[0].Target != "usr" {
+		t.Errorf("expected usr cached, got %v", cached)
+	}
+	if len(mounts) != 2 || mounts[0].Target != "w" || mounts[1].Target != "w/bin" {
+		t.Errorf("expected w and w/bin performed by each container, got %v", mounts)
+	}
+	if len(mountPoints) != 1 || mountPoints[0] != "w" {
+		t.Errorf("expected mount point w, got %v", mountPoints)
+	}
+}
+
+// rootSuperReadOnly checks the super options of the root in mountinfo
+func rootSuperReadOnly(mountinfo string) bool {
+	for _, l := range strings.Split(mountinfo, "\n") {
+		f := strings.Fields(l)
+		if len(f) < 5 || f[4] != "/" {
+			continue
+		}
+		_, post, ok := strings.Cut(l, " - ")
+		if !ok {
+			return false
+		}
+		p := strings.Fields(post)
+		return len(p) >= 3 && strings.HasPrefix(p[2], "ro")
+	}
+	return false
+}
+
+func TestContainerLogger(t *testing.T) {
+	var buf bytes.Buffer
+	builder := &Builder{
+		Root:   t.TempDir(),
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	m, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		m.Destroy()
+	})
+	r := m.Execve(context.TODO(), successParam)
+	if r.Status != runner.StatusNormal {
+		t.Fatal(r.Status, r.Error, r)
+	}
+	// execve started is logged inside the container init
+	for _, s := range []string{`msg="execve started"`, "path=/bin/true", `msg="execve finished"`} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected log %q, got %s", s, buf.String())
+		}
+	}
+}
+
+func BenchmarkContainerBuild(b *testing.B) {
+	builder := &Builder{
+		Root:   b.TempDir(),
+		Stderr: os.Stderr,
+	}
+	benchmarkBuild(b, builder)
+}
+
+func BenchmarkContainerBuildMountTree(b *testing.B) {
+	builder := &Builder{
+		Root:   b.TempDir(),
+		Stderr: os.Stderr,
+	}
+	tree, err := builder.PrepareMountTree()
+	if err != nil {
+		b.Skip("mount tree not supported:", err)
+	}
+	b.Cleanup(func() {
+		tree.Destroy()
+	})
+	builder.MountTree = tree
+	benchmarkBuild(b, builder)
+}
+
+func benchmarkBuild(b *testing.B, builder *Builder) {
+	for i := 0; i < b.N; i++ {
+		m, err := builder.Build()
+		if err != nil {
+			b.Fatal(err)
+		}
+		m.Destroy()
+	}
+}
+
+func TestContainerIDMappedBind(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("root required for this test")
+	}
+	dataDir := t.TempDir()
+	if err := os.Chmod(dataDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	builder := &Builder{
+		Root:          t.TempDir(),
+		Stderr:        os.Stderr,
+		CredGenerator: credgen{},
+		Mounts: mount.NewDefaultBuilder().
+			WithTmpfs("w", "").
+			WithTmpfs("tmp", "").
+			WithIDMappedBind(dataDir, "data", false).
+			FilterNotExist().Mounts,
+	}
+	skipIfNoIDMappedMount(t, dataDir)
+	m, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		m.Destroy()
+	})
+	r := m.Execve(context.TODO(), ExecveParam{
+		Args: []string{"/bin/touch", "/data/a"},
+		Env:  []string{"PATH=/bin"},
+	})
+	if r.Status != runner.StatusNormal {
+		t.Fatal(r.Status, r.Error, r)
+	}
+	fi, err := os.Stat(filepath.Join(dataDir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written as the container uid rather than the generated host uid
+	if uid := fi.Sys().(*syscall.Stat_t).Uid; uid != containerUID {
+		t.Errorf("expected owner %d, got %d", containerUID, uid)
+	}
+}
+
+// skipIfNoIDMappedMount skips the test if the kernel or the file system of dir
+// does not support id-mapped mounts
+func skipIfNoIDMappedMount(t *testing.T, dir string) {
+	t.Helper()
+	cmd := exec.Command("/bin/sleep", "10")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: containerUID, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: containerUID, Size: 1}},
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skip("user namespace not supported:", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	userns, err := os.Open(fmt.Sprintf("/proc/%d/ns/user", cmd.Process.Pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer userns.Close()
+
+	m := mount.Mount{Source: dir, Flags: syscall.MS_BIND}
+	fd, err := m.OpenIDMapped(int(userns.Fd()))
+	switch {
+	case errors.Is(err, syscall.ENOSYS), errors.Is(err, syscall.EINVAL), errors.Is(err, syscall.EPERM):
+		t.Skip("id-mapped mount not supported:", err)
+	case err != nil:
+		t.Fatal(err)
+	}
+	syscall.Close(fd)
+}
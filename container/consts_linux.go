@@ -12,6 +12,7 @@ const (
 	cmdKill
 	cmdConf
 	cmdSymlink
+	cmdCloneTree
 
 	initArg = "container_init"
 
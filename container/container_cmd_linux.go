@@ -4,21 +4,34 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/criyle/go-sandbox/pkg/unixsocket"
+	"golang.org/x/sys/unix"
 )
 
 func (c *containerServer) handlePing() error {
 	return c.sendReply(reply{}, unixsocket.Msg{})
 }
 
-func (c *containerServer) handleConf(conf *confCmd) error {
+func (c *containerServer) handleConf(conf *confCmd, msg unixsocket.Msg) error {
+	defer closeFds(msg.Fds)
 	if conf != nil {
 		c.containerConfig = conf.Conf
-		if err := initContainer(conf.Conf); err != nil {
+		if conf.Conf.Log {
+			c.log = slog.New(&socketHandler{c: c, level: conf.Conf.LogLevel})
+		}
+		treeFd, fds := -1, msg.Fds
+		if conf.Conf.MountTree {
+			if len(fds) == 0 {
+				return fmt.Errorf("conf: expected mount tree fd")
+			}
+			treeFd, fds = fds[0], fds[1:]
+		}
+		if err := initContainer(conf.Conf, treeFd, fds, c.log); err != nil {
 			return err
 		}
 		if c.ContainerUID == 0 {
@@ -36,6 +49,18 @@ func (c *containerServer) handleConf(conf *confCmd) error {
 	return c.sendReply(reply{}, unixsocket.Msg{})
 }
 
+func (c *containerServer) handleCloneTree() error {
+	if !c.Template {
+		return c.sendErrorReply("clone_tree: not a template container")
+	}
+	fd, err := unix.OpenTree(unix.AT_FDCWD, "/", unix.OPEN_TREE_CLONE|unix.OPEN_TREE_CLOEXEC|unix.AT_RECURSIVE)
+	if err != nil {
+		return c.sendErrorReply("clone_tree: open_tree: %v", err)
+	}
+	f := os.NewFile(uintptr(fd), "mount_tree")
+	return c.sendReplyFiles(reply{}, unixsocket.Msg{Fds: []int{fd}}, []*os.File{f})
+}
+
 func (c *containerServer) handleOpen(open []OpenCmd) error {
 	if len(open) == 0 {
 		return c.sendErrorReply("open: no open parameter received")
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/criyle/go-sandbox/pkg/forkexec"
+	"github.com/criyle/go-sandbox/pkg/rlimit"
 	"github.com/criyle/go-sandbox/pkg/unixsocket"
 	"github.com/criyle/go-sandbox/runner"
 )
@@ -121,23 +122,26 @@ func (c *containerServer) handleExecve(cmd *execCmd, msg unixsocket.Msg) error {
 		}
 		return c.sendErrorReply("start: %s: %v", s, err)
 	}
+	if len(cmd.Argv) > 0 {
+		c.log.Debug("execve started", "pid", pid, "path", cmd.Argv[0])
+	}
 	if cmd.SyncAfter {
 		if err := syncPid(1); err != nil {
 			syscall.Kill(-1, syscall.SIGKILL)
 
 			c.waitPid <- pid
 			ret := <-c.waitPidResult
-			err := c.sendReply(convertReply(ret), unixsocket.Msg{})
+			err := c.sendReply(convertReply(ret, cmd.RLimits), unixsocket.Msg{})
 
 			c.waitAll <- struct{}{}
 			<-c.waitAllDone
 			return err
 		}
 	}
-	return c.handleExecveStarted(pid)
+	return c.handleExecveStarted(pid, cmd.RLimits)
 }
 
-func (c *containerServer) handleExecveStarted(pid int) error {
+func (c *containerServer) handleExecveStarted(pid int, rlimits []rlimit.RLimit) error {
 	// At this point, either recv kill / send result would be happened
 	// host -> container: kill
 	// container -> host: result
@@ -156,7 +160,7 @@ func (c *containerServer) handleExecveStarted(pid int) error {
 		ret = <-c.waitPidResult
 		c.waitAll <- struct{}{}
 
-		if err := c.sendReply(convertReply(ret), unixsocket.Msg{}); err != nil {
+		if err := c.sendReply(convertReply(ret, rlimits), unixsocket.Msg{}); err != nil {
 			return err
 		}
 
@@ -164,7 +168,7 @@ func (c *containerServer) handleExecveStarted(pid int) error {
 		syscall.Kill(-1, syscall.SIGKILL)
 		c.waitAll <- struct{}{}
 
-		if err := c.sendReply(convertReply(ret), unixsocket.Msg{}); err != nil {
+		if err := c.sendReply(convertReply(ret, rlimits), unixsocket.Msg{}); err != nil {
 			return err
 		}
 		if _, _, err := c.recvCmd(); err != nil { // kill cmd received
@@ -175,7 +179,7 @@ func (c *containerServer) handleExecveStarted(pid int) error {
 	return nil
 }
 
-func convertReply(ret waitPidResult) reply {
+func convertReply(ret waitPidResult, rlimits []rlimit.RLimit) reply {
 	if ret.Err != nil {
 		return reply{
 			Error: &errorReply{
@@ -206,21 +210,18 @@ func convertReply(ret waitPidResult) reply {
 		}
 
 	case waitStatus.Signaled():
-		switch waitStatus.Signal() {
-		// kill signal treats as TLE
-		case syscall.SIGXCPU, syscall.SIGKILL:
-			status = runner.StatusTimeLimitExceeded
-		case syscall.SIGXFSZ:
-			status = runner.StatusOutputLimitExceeded
-		case syscall.SIGSYS:
-			status = runner.StatusDisallowedSyscall
-		default:
-			status = runner.StatusSignalled
+		sig := waitStatus.Signal()
+		status, cause := runner.SignalStatus(sig)
+		// killed by the kernel on hard cpu limit
+		cpuTime := time.Duration(rusage.Utime.Nano() + rusage.Stime.Nano())
+		if sig == syscall.SIGKILL && rlimit.CPUHardLimitExceeded(rlimits, cpuTime) {
+			cause = runner.CauseCPUTimeLimit
 		}
 		return reply{
 			ExecReply: &execReply{
-				ExitStatus: int(waitStatus.Signal()),
+				ExitStatus: int(sig),
 				Status:     status,
+				Cause:      cause,
 				Time:       userTime,
 				Memory:     userMem,
 			},
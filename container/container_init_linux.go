@@ -3,6 +3,7 @@ package container
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -13,12 +14,14 @@ import (
 	"syscall"
 
 	"github.com/criyle/go-sandbox/pkg/unixsocket"
+	"golang.org/x/sys/unix"
 )
 
 type containerServer struct {
 	socket *socket
 	containerConfig
 	defaultEnv []string
+	log        *slog.Logger
 
 	done     chan struct{}
 	err      error
@@ -106,6 +109,7 @@ func Init() (err error) {
 	// serve forever
 	cs := &containerServer{
 		socket:        newSocket(soc),
+		log:           slog.New(slog.DiscardHandler),
 		done:          make(chan struct{}),
 		sendCh:        make(chan sendReply, 1),
 		recvCh:        make(chan recvCmd, 1),
@@ -212,6 +216,7 @@ func (c *containerServer) serve() error {
 			return fmt.Errorf("serve: recvCmd: %w", err)
 		}
 		if err := c.handleCmd(cmd, msg); err != nil {
+			c.log.Error("container_init: failed to execute cmd", "cmd", cmd.Cmd, "err", err)
 			return fmt.Errorf("serve: failed to execute cmd: %w", err)
 		}
 	}
@@ -223,7 +228,7 @@ func (c *containerServer) handleCmd(cmd cmd, msg unixsocket.Msg) error {
 		return c.handlePing()
 
 	case cmdConf:
-		return c.handleConf(cmd.ConfCmd)
+		return c.handleConf(cmd.ConfCmd, msg)
 
 	case cmdOpen:
 		return c.handleOpen(cmd.OpenCmd)
@@ -239,12 +244,15 @@ func (c *containerServer) handleCmd(cmd cmd, msg unixsocket.Msg) error {
 
 	case cmdSymlink:
 		return c.handleSymlink(cmd.SymlinkCmd)
+
+	case cmdCloneTree:
+		return c.handleCloneTree()
 	}
 	return fmt.Errorf("unknown command: %v", cmd.Cmd)
 }
 
-func initContainer(c containerConfig) error {
-	if err := initFileSystem(c); err != nil {
+func initContainer(c containerConfig, treeFd int, idMapFds []int, log *slog.Logger) error {
+	if err := initFileSystem(c, treeFd, idMapFds); err != nil {
 		return err
 	}
 	if err := syscall.Setdomainname([]byte(c.DomainName)); err != nil {
@@ -258,19 +266,28 @@ func initContainer(c containerConfig) error {
 	}
 	if len(c.InitCommand) > 0 {
 		cm := exec.Command(c.InitCommand[0], c.InitCommand[1:]...)
-		if output, err := cm.CombinedOutput(); err != nil {
-			os.Stderr.Write(output)
+		output, err := cm.CombinedOutput()
+		if err != nil {
+			log.Error("init command failed", "command", c.InitCommand, "output", string(output), "err", err)
 			return err
 		}
+		log.Debug("init command finished", "command", c.InitCommand, "output", string(output))
 	}
 	return nil
 }
 
-func initFileSystem(c containerConfig) error {
-	// mount tmpfs as root
+func initFileSystem(c containerConfig, treeFd int, idMapFds []int) error {
 	const tmpfs = "tmpfs"
-	if err := syscall.Mount(tmpfs, c.ContainerRoot, tmpfs, 0, ""); err != nil {
-		return fmt.Errorf("init_fs: mount /: %w", err)
+	if c.MountTree {
+		// attach the cloned mount tree as root
+		if err := unix.MoveMount(treeFd, "", unix.AT_FDCWD, c.ContainerRoot, unix.MOVE_MOUNT_F_EMPTY_PATH); err != nil {
+			return fmt.Errorf("init_fs: move_mount /: %w", err)
+		}
+	} else {
+		// mount tmpfs as root
+		if err := syscall.Mount(tmpfs, c.ContainerRoot, tmpfs, 0, ""); err != nil {
+			return fmt.Errorf("init_fs: mount /: %w", err)
+		}
 	}
 	// change dir to container root
 	if err := syscall.Chdir(c.ContainerRoot); err != nil {
@@ -278,23 +295,54 @@ func initFileSystem(c containerConfig) error {
 	}
 	// performing mounts
 	for _, m := range c.Mounts {
+		if m.IDMapped {
+			// attach ID-mapped mount created by the host
+			if len(idMapFds) == 0 {
+				return fmt.Errorf("init_fs: mount %v: missing id-mapped mount fd", m)
+			}
+			if err := m.MoveMount(idMapFds[0]); err != nil {
+				return fmt.Errorf("init_fs: mount %v: %w", m, err)
+			}
+			idMapFds = idMapFds[1:]
+			continue
+		}
 		if err := m.Mount(); err != nil {
 			return fmt.Errorf("init_fs: mount %v: %w", m, err)
 		}
 	}
-	// pivot root
-	const oldRoot = "old_root"
-	if err := os.Mkdir(oldRoot, 0755); err != nil {
-		return fmt.Errorf("init_fs: mkdir old_root: %w", err)
-	}
-	if err := syscall.PivotRoot(c.ContainerRoot, oldRoot); err != nil {
-		return fmt.Errorf("init_fs: pivot_root(%s, %s): %w", c.ContainerRoot, oldRoot, err)
-	}
-	if err := syscall.Unmount(oldRoot, syscall.MNT_DETACH); err != nil {
-		return fmt.Errorf("init_fs: unmount old_root: %w", err)
+	// mount points for containers cloned from the template
+	for _, p := range c.MountPoints {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			return fmt.Errorf("init_fs: mkdir_all(%s): %w", p, err)
+		}
 	}
-	if err := os.Remove(oldRoot); err != nil {
-		return fmt.Errorf("init_fs: unlink old_root: %w", err)
+	// pivot root
+	if c.MountTree {
+		// the root of the mount tree is read-only, stack the old root on top of
+		// the new root and detach it instead of creating the old_root directory
+		if err := syscall.PivotRoot(".", "."); err != nil {
+			return fmt.Errorf("init_fs: pivot_root(%s, %s): %w", c.ContainerRoot, c.ContainerRoot, err)
+		}
+		if err := syscall.Unmount(".", syscall.MNT_DETACH); err != nil {
+			return fmt.Errorf("init_fs: unmount old_root: %w", err)
+		}
+		if err := syscall.Chdir("/"); err != nil {
+			return fmt.Errorf("init_fs: chdir: %w", err)
+		}
+	} else {
+		const oldRoot = "old_root"
+		if err := os.Mkdir(oldRoot, 0755); err != nil {
+			return fmt.Errorf("init_fs: mkdir old_root: %w", err)
+		}
+		if err := syscall.PivotRoot(c.ContainerRoot, oldRoot); err != nil {
+			return fmt.Errorf("init_fs: pivot_root(%s, %s): %w", c.ContainerRoot, oldRoot, err)
+		}
+		if err := syscall.Unmount(oldRoot, syscall.MNT_DETACH); err != nil {
+			return fmt.Errorf("init_fs: unmount old_root: %w", err)
+		}
+		if err := os.Remove(oldRoot); err != nil {
+			return fmt.Errorf("init_fs: unlink old_root: %w", err)
+		}
 	}
 	// create symlinks
 	for _, l := range c.SymbolicLinks {
@@ -313,6 +361,15 @@ func initFileSystem(c containerConfig) error {
 			return fmt.Errorf("init_fs: mask path: %w", err)
 		}
 	}
+	// the template root is cloned by all containers, make the tmpfs read-only
+	// at superblock level since the read-only bind remount could be reverted
+	// by the container
+	if c.Template {
+		const sbFlag = syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_NOATIME | syscall.MS_NOSUID
+		if err := syscall.Mount(tmpfs, "/", tmpfs, sbFlag, ""); err != nil {
+			return fmt.Errorf("init_fs: readonly superblock remount /: %w", err)
+		}
+	}
 	// readonly root
 	const remountFlag = syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_NOATIME | syscall.MS_NOSUID
 	if err := syscall.Mount(tmpfs, "/", tmpfs, remountFlag, ""); err != nil {
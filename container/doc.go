@@ -19,9 +19,14 @@
 //
 // ## conf (set configuration)
 //
-// - send: conf
+// - send: conf, mount tree fd (if cloned from mount tree)
 // - reply:
 //
+// ## clone_tree (clone the mount tree from template container):
+//
+// - send:
+// - reply: mount tree fd / "error"
+//
 // ## open (open files in given mode by container-visible path):
 //
 // - send: []OpenCmd
@@ -48,5 +53,9 @@
 // - send: "kill" (as cmd) / reply: "finished"
 // - reply:
 //
+// ## log (container init logs, if Builder.Logger is set):
+//
+// - reply: log record (at any time, not a reply to any cmd)
+//
 // Any socket related error will cause the container exit with all process inside container
 package container
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"sync"
@@ -27,7 +28,8 @@ type Builder struct {
 	// TmpRoot defines the tmp dir pattern if not nil. Temp directory will be created as container root dir
 	TmpRoot string
 
-	// Mounts defines container mount points, empty uses default mounts
+	// Mounts defines container mount points, empty uses default mounts.
+	// ID-mapped bind mounts are created by the host and requires CAP_SYS_ADMIN
 	Mounts []mount.Mount
 
 	// SymbolicLinks defines symlinks to be created after mount file system
@@ -43,6 +45,10 @@ type Builder struct {
 	// Stderr defines whether to dup container stderr to stderr for debug
 	Stderr io.Writer
 
+	// Logger receives structured logs of the container. Logs of the container
+	// init are forwarded over the control socket
+	Logger *slog.Logger
+
 	// ExecFile defines executable that called Init, otherwise defer current
 	// executable (/proc/self/exe)
 	ExecFile string
@@ -69,6 +75,10 @@ type Builder struct {
 
 	// UnshareCgroupBeforeExec calls unshare cgroup before execution
 	UnshareCgroupBeforeExec bool
+
+	// MountTree defines prepared mount tree to clone as container root file system,
+	// Mounts and SymbolicLinks are taken from the builder that prepared it
+	MountTree *MountTree
 }
 
 // SymbolicLink defines symlinks to be created after mount
@@ -96,9 +106,10 @@ type Environment interface {
 
 // container manages single pre-forked container environment
 type container struct {
-	process *os.Process // underlying container init pid
-	socket  *socket     // host - container communication
-	mu      sync.Mutex  // lock to avoid race condition
+	process *os.Process  // underlying container init pid
+	socket  *socket      // host - container communication
+	mu      sync.Mutex   // lock to avoid race condition
+	logger  *slog.Logger // logs of the container
 
 	done     chan struct{}
 	err      error
@@ -131,39 +142,47 @@ func (b *Builder) Build() (Environment, error) {
 		return nil, fmt.Errorf("container: init not responding to ping: %w", err)
 	}
 
-	// container mount points
-	mounts := b.Mounts
-	if len(mounts) == 0 {
-		mounts = mount.NewDefaultBuilder().
-			WithTmpfs("w", "").   // work dir
-			WithTmpfs("tmp", ""). // tmp
-			FilterNotExist().Mounts
-	}
-
-	// container symbolic links
-	links := b.SymbolicLinks
-	if len(links) == 0 {
-		links = defaultSymLinks
-	}
+	// container mount points and symbolic links
+	mounts := b.mounts()
+	links := b.symbolicLinks()
 
 	maskPaths := b.MaskPaths
 	if len(maskPaths) == 0 {
 		maskPaths = defaultMaskPaths
 	}
 
+	// clone mount tree from the template container
+	var msg unixsocket.Msg
+	if b.MountTree != nil {
+		tree, err := b.MountTree.clone()
+		if err != nil {
+			c.Destroy()
+			return nil, fmt.Errorf("container: %w", err)
+		}
+		defer tree.Close()
+		mounts, links = b.MountTree.mounts, nil
+		msg.Fds = []int{int(tree.Fd())}
+	}
+
 	// container root directory on the host
-	root := b.Root
+	root, err := b.rootDir()
+	if err != nil {
+		c.Destroy()
+		return nil, err
+	}
 	if b.TmpRoot != "" {
-		if root, err = os.MkdirTemp(b.Root, b.TmpRoot); err != nil {
-			return nil, fmt.Errorf("container: failed to make tmp container root at %s: %w", b.Root, err)
-		}
 		defer os.Remove(root)
 	}
-	if root == "" {
-		if root, err = os.Getwd(); err != nil {
-			return nil, fmt.Errorf("container: failed to get work directory: %w", err)
-		}
+
+	// ID-mapped mounts are created by the host with the container user namespace
+	idMapFds, err := openIDMappedMounts(c.process.Pid, mounts)
+	if err != nil {
+		c.Destroy()
+		return nil, fmt.Errorf("container: %w", err)
 	}
+	defer closeFds(idMapFds)
+	msg.Fds = append(msg.Fds, idMapFds...)
+
 	workDir := containerWD
 	if b.WorkDir != "" {
 		workDir = b.WorkDir
@@ -191,13 +210,79 @@ func (b *Builder) Build() (Environment, error) {
 		ContainerUID:  b.ContainerUID,
 		ContainerGID:  b.ContainerGID,
 		UnshareCgroup: b.UnshareCgroupBeforeExec,
-	}); err != nil {
+		MountTree:     b.MountTree != nil,
+		Log:           b.Logger != nil,
+		LogLevel:      logLevel(c.logger),
+	}, msg); err != nil {
 		c.Destroy()
 		return nil, err
 	}
 	return c, nil
 }
 
+// mounts returns container mount points, default mounts if empty
+func (b *Builder) mounts() []mount.Mount {
+	if len(b.Mounts) > 0 {
+		return b.Mounts
+	}
+	return mount.NewDefaultBuilder().
+		WithTmpfs("w", "").   // work dir
+		WithTmpfs("tmp", ""). // tmp
+		FilterNotExist().Mounts
+}
+
+// symbolicLinks returns container symbolic links, default links if empty
+func (b *Builder) symbolicLinks() []SymbolicLink {
+	if len(b.SymbolicLinks) > 0 {
+		return b.SymbolicLinks
+	}
+	return defaultSymLinks
+}
+
+// rootDir returns container root directory on the host
+func (b *Builder) rootDir() (string, error) {
+	root := b.Root
+	if b.TmpRoot != "" {
+		var err error
+		if root, err = os.MkdirTemp(b.Root, b.TmpRoot); err != nil {
+			return "", fmt.Errorf("container: failed to make tmp container root at %s: %w", b.Root, err)
+		}
+	}
+	if root == "" {
+		var err error
+		if root, err = os.Getwd(); err != nil {
+			return "", fmt.Errorf("container: failed to get work directory: %w", err)
+		}
+	}
+	return root, nil
+}
+
+// openIDMappedMounts creates detached ID-mapped mounts for mounts that requested
+// it, using the user namespace of the container init process
+func openIDMappedMounts(pid int, mounts []mount.Mount) ([]int, error) {
+	var userns *os.File
+	fds := make([]int, 0)
+	for _, m := range mounts {
+		if !m.IDMapped {
+			continue
+		}
+		if userns == nil {
+			var err error
+			if userns, err = os.Open(fmt.Sprintf("/proc/%d/ns/user", pid)); err != nil {
+				return nil, fmt.Errorf("open user namespace: %w", err)
+			}
+			defer userns.Close()
+		}
+		fd, err := m.OpenIDMapped(int(userns.Fd()))
+		if err != nil {
+			closeFds(fds)
+			return nil, fmt.Errorf("id-mapped mount %v: %w", m, err)
+		}
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
 func (b *Builder) startContainer() (*container, error) {
 	var (
 		err            error
@@ -264,6 +349,7 @@ func (b *Builder) startContainer() (*container, error) {
 	c := &container{
 		process: r.Process,
 		socket:  newSocket(ins),
+		logger:  runner.Logger(b.Logger, false),
 		recvCh:  make(chan recvReply, 1),
 		sendCh:  make(chan sendCmd, 1),
 		done:    make(chan struct{}),
@@ -300,6 +386,10 @@ func (c *container) recvLoop() {
 			c.socketError(err)
 			return
 		}
+		if reply.Log != nil {
+			c.log(reply.Log)
+			continue
+		}
 		c.recvCh <- recvReply{
 			Reply: reply,
 			Msg:   msg,
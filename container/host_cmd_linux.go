@@ -32,7 +32,7 @@ func (c *container) Ping() error {
 }
 
 // conf send configuration to container (used by builder only)
-func (c *container) conf(conf *containerConfig) error {
+func (c *container) conf(conf *containerConfig, msg unixsocket.Msg) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -40,12 +40,39 @@ func (c *container) conf(conf *containerConfig) error {
 		Cmd:     cmdConf,
 		ConfCmd: &confCmd{Conf: *conf},
 	}
-	if err := c.sendCmd(cmd, unixsocket.Msg{}); err != nil {
+	if err := c.sendCmd(cmd, msg); err != nil {
 		return fmt.Errorf("conf: %w", err)
 	}
 	return c.recvAckReply("conf")
 }
 
+// cloneTree clones the detached mount tree from template container (used by builder only)
+func (c *container) cloneTree() (*os.File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cmd := cmd{
+		Cmd: cmdCloneTree,
+	}
+	if err := c.sendCmd(cmd, unixsocket.Msg{}); err != nil {
+		return nil, fmt.Errorf("clone_tree: %w", err)
+	}
+	reply, msg, err := c.recvReply()
+	if err != nil {
+		return nil, fmt.Errorf("clone_tree: %w", err)
+	}
+	if reply.Error != nil {
+		closeFds(msg.Fds)
+		return nil, fmt.Errorf("clone_tree: container error: %v", reply.Error)
+	}
+	if len(msg.Fds) != 1 {
+		closeFds(msg.Fds)
+		return nil, fmt.Errorf("clone_tree: expected 1 fd, got %d", len(msg.Fds))
+	}
+	syscall.CloseOnExec(msg.Fds[0])
+	return os.NewFile(uintptr(msg.Fds[0]), "mount_tree"), nil
+}
+
 // Open open files in container
 func (c *container) Open(p []OpenCmd) (results []OpenCmdResult, err error) {
 	c.mu.Lock()
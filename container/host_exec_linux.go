@@ -34,6 +34,17 @@ type ExecveParam struct {
 	// Seccomp specifies seccomp filter
 	Seccomp seccomp.Filter
 
+	// Limit specifies the wall time and output limit enforced on the process.
+	// CPU time and memory are left to the caller (e.g. by cgroup or Sampler).
+	// ProcessLimit is not supported and results in StatusRunnerError, set
+	// cgroup pids.max instead since RLIMIT_NPROC counts all processes of the
+	// user
+	Limit runner.Limit
+
+	// Sampler enforces Limit (including CPU time and memory) during the run
+	// and records the usage of the process by its host pid (optional)
+	Sampler *runner.Sampler
+
 	// CTTY specifies whether to set controlling TTY
 	CTTY bool
 
@@ -47,9 +58,16 @@ type ExecveParam struct {
 
 // Execve runs process inside container. It accepts context cancellation as time limit exceeded.
 func (c *container) Execve(ctx context.Context, param ExecveParam) runner.Result {
+	if param.Limit.ProcessLimit > 0 {
+		return errResult("execve: process limit is not supported, use cgroup pids.max")
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	ctx, cancel := param.Limit.WithWallTimeLimit(ctx)
+	defer cancel()
+
 	sTime := time.Now()
 
 	// if execve with fd, put fd at the first parameter
@@ -67,7 +85,7 @@ func (c *container) Execve(ctx context.Context, param ExecveParam) runner.Result
 	execCmd := &execCmd{
 		Argv:      param.Args,
 		Env:       param.Env,
-		RLimits:   param.RLimits,
+		RLimits:   rlimit.ApplyLimit(param.RLimits, param.Limit),
 		Seccomp:   param.Seccomp,
 		FdExec:    param.ExecFile > 0,
 		CTTY:      param.CTTY,
@@ -110,8 +128,23 @@ func (c *container) Execve(ctx context.Context, param ExecveParam) runner.Result
 		return errResult("execve: ack failed %v", err)
 	}
 
-	// wait for done
-	return c.waitForDone(ctx, sTime)
+	// wait for done, the sampler kills the process by cancelling the wait
+	waitCtx := ctx
+	var sampling *runner.Sampling
+	if param.Sampler != nil {
+		var stop context.CancelFunc
+		waitCtx, stop = context.WithCancel(ctx)
+		defer stop()
+		sampling = param.Sampler.Start(int(msg.Cred.Pid), param.Limit, stop)
+	}
+	result := c.waitForDone(waitCtx, sTime)
+	if sampling != nil {
+		sampling.Finish(&result)
+	}
+	param.Limit.CheckContext(ctx, &result)
+	c.logger.Debug("execve finished", "args", param.Args, "status", result.Status, "cause", result.Cause,
+		"elapsed", time.Since(sTime))
+	return result
 }
 
 func (c *container) waitForDone(ctx context.Context, sTime time.Time) runner.Result {
@@ -154,6 +187,7 @@ func convertReplyResult(reply reply, sTime, mTime time.Time, err error) runner.R
 	// emit result after all communication finish
 	return runner.Result{
 		Status:      reply.ExecReply.Status,
+		Cause:       reply.ExecReply.Cause,
 		ExitStatus:  reply.ExecReply.ExitStatus,
 		Time:        reply.ExecReply.Time,
 		Memory:      reply.ExecReply.Memory,
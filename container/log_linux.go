@@ -0,0 +1,109 @@
+package container
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/criyle/go-sandbox/pkg/unixsocket"
+)
+
+// logReply is a log record of the container init forwarded to the host
+type logReply struct {
+	Time  time.Time
+	Level slog.Level
+	Msg   string
+	Attrs []logAttr
+}
+
+// logAttr is a log attribute with its value formatted as string
+type logAttr struct {
+	Key   string
+	Value string
+}
+
+// logLevel returns the minimum level enabled by the logger. Level above
+// LevelError is returned if nothing enabled
+func logLevel(l *slog.Logger) slog.Level {
+	for _, lv := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		if l.Enabled(context.Background(), lv) {
+			return lv
+		}
+	}
+	return slog.LevelError + 1
+}
+
+// log writes the log record forwarded from the container into the host logger
+func (c *container) log(r *logReply) {
+	attrs := make([]slog.Attr, 0, len(r.Attrs)+1)
+	attrs = append(attrs, slog.Int("container", c.process.Pid))
+	for _, a := range r.Attrs {
+		attrs = append(attrs, slog.String(a.Key, a.Value))
+	}
+	rec := slog.NewRecord(r.Time, r.Level, r.Msg, 0)
+	rec.AddAttrs(attrs...)
+	c.logger.Handler().Handle(context.Background(), rec)
+}
+
+// socketHandler is the slog handler inside container init that forwards log
+// records to the host over the control socket
+type socketHandler struct {
+	c      *containerServer
+	level  slog.Level
+	attrs  []logAttr
+	prefix string
+}
+
+func (h *socketHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level
+}
+
+func (h *socketHandler) Handle(_ context.Context, r slog.Record) error {
+	rep := &logReply{
+		Time:  r.Time,
+		Level: r.Level,
+		Msg:   r.Message,
+		Attrs: append([]logAttr(nil), h.attrs...),
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rep.Attrs = appendLogAttr(rep.Attrs, h.prefix, a)
+		return true
+	})
+	return h.c.sendReply(reply{Log: rep}, unixsocket.Msg{})
+}
+
+func (h *socketHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.attrs = append([]logAttr(nil), h.attrs...)
+	for _, a := range attrs {
+		nh.attrs = appendLogAttr(nh.attrs, h.prefix, a)
+	}
+	return &nh
+}
+
+func (h *socketHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	nh := *h
+	nh.prefix = h.prefix + name + "."
+	return &nh
+}
+
+// appendLogAttr flattens groups into dotted keys
+func appendLogAttr(attrs []logAttr, prefix string, a slog.Attr) []logAttr {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			attrs = appendLogAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	if a.Key == "" {
+		return attrs
+	}
+	return append(attrs, logAttr{Key: prefix + a.Key, Value: v.String()})
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/criyle/go-sandbox/pkg/mount"
+	"github.com/criyle/go-sandbox/pkg/unixsocket"
+)
+
+// MountTree holds a template container with the container root file system
+// prepared once. Containers built with the mount tree attach a clone of the
+// detached tree (open_tree with OPEN_TREE_CLONE) by move_mount instead of
+// performing every bind mount again. It requires the new mount API (Linux >= 5.2).
+//
+// Bind mounts that are not ID-mapped nor beneath any other kind of mount
+// (e.g. tmpfs) are cached in the tree together with the symbolic links. The cached tree is
+// read-only and the remaining mounts are performed by each container on top
+// of it. The tmpfs root of the tree is shared by all the containers, so it is
+// made read-only at superblock level so that it could not be remounted
+// read-write by any of them.
+type MountTree struct {
+	c      *container
+	mounts []mount.Mount // mounts performed by each container
+}
+
+// PrepareMountTree starts the template container that holds the mount tree
+// for the Mounts and SymbolicLinks of the builder. It returns error if the
+// kernel does not support the new mount API, containers should be built
+// without MountTree in that case
+func (b *Builder) PrepareMountTree() (*MountTree, error) {
+	// parent mount points must be split before their children
+	ms, err := (&mount.Builder{Mounts: b.mounts()}).Validate()
+	if err != nil {
+		return nil, err
+	}
+	c, err := b.startContainer()
+	if err != nil {
+		return nil, err
+	}
+	if err = c.Ping(); err != nil {
+		c.Destroy()
+		return nil, fmt.Errorf("container: init not responding to ping: %w", err)
+	}
+
+	cached, mounts, mountPoints := splitMountTree(ms)
+	root, err := b.rootDir()
+	if err != nil {
+		c.Destroy()
+		return nil, err
+	}
+	if b.TmpRoot != "" {
+		defer os.Remove(root)
+	}
+	if err = c.conf(&containerConfig{
+		WorkDir:       "/",
+		HostName:      containerName,
+		DomainName:    containerName,
+		ContainerRoot: root,
+		Mounts:        cached,
+		SymbolicLinks: b.symbolicLinks(),
+		MountPoints:   mountPoints,
+		Template:      true,
+		Log:           b.Logger != nil,
+		LogLevel:      logLevel(c.logger),
+	}, unixsocket.Msg{}); err != nil {
+		c.Destroy()
+		return nil, err
+	}
+
+	// ensure the kernel is able to clone the tree
+	f, err := c.cloneTree()
+	if err != nil {
+		c.Destroy()
+		return nil, fmt.Errorf("container: %w", err)
+	}
+	f.Close()
+
+	return &MountTree{c: c, mounts: mounts}, nil
+}
+
+// Destroy kills the template container
+func (t *MountTree) Destroy() error {
+	return t.c.Destroy()
+}
+
+func (t *MountTree) clone() (*os.File, error) {
+	return t.c.cloneTree()
+}
+
+// splitMountTree separates bind mounts to be cached in the mount tree from the
+// mounts performed by each container, and the mount points of the latter that
+// need to exist in the read-only tree. The mounts must be ordered by
+// mount.Builder.Validate
+func splitMountTree(ms []mount.Mount) (cached, mounts []mount.Mount, mountPoints []string) {
+	for _, m := range ms {
+		beneath := isBeneath(m.Target, mounts)
+		if m.IsBindMount() && !m.IDMapped && !beneath {
+			cached = append(cached, m)
+			continue
+		}
+		mounts = append(mounts, m)
+		if !beneath {
+			mountPoints = append(mountPoints, m.Target)
+		}
+	}
+	return cached, mounts, mountPoints
+}
+
+func isBeneath(target string, ms []mount.Mount) bool {
+	t := filepath.Clean(target)
+	for _, m := range ms {
+		if strings.HasPrefix(t, filepath.Clean(m.Target)+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"log/slog"
 	"os"
 	"syscall"
 	"time"
@@ -77,6 +78,18 @@ type containerConfig struct {
 	ContainerGID  int
 	Cred          bool
 	UnshareCgroup bool
+
+	// MountPoints are directories created in the template root for mounts
+	// performed by each container on top of the cloned mount tree
+	MountPoints []string
+	// Template keeps the container as the holder of the prepared mount tree
+	Template bool
+	// MountTree indicates the root mount tree is passed as fd along with conf
+	MountTree bool
+
+	// Log enables forwarding logs at or above LogLevel to the host
+	Log      bool
+	LogLevel slog.Level
 }
 
 // reply is the reply message send back to controller
@@ -84,6 +97,7 @@ type reply struct {
 	Error       *errorReply // nil if no error
 	ExecReply   *execReply
 	BatchErrors []string
+	Log         *logReply // log record of container init, not a reply to cmd
 }
 
 // errorReply stores error returned back from container
@@ -96,6 +110,7 @@ type errorReply struct {
 type execReply struct {
 	ExitStatus int           // waitpid exit status
 	Status     runner.Status // return status
+	Cause      runner.Cause  // terminating cause
 	Time       time.Duration // waitpid user CPU (ns)
 	Memory     runner.Size   // waitpid user memory (byte)
 }
@@ -4,6 +4,7 @@ go 1.25.0
 
 require (
 	github.com/elastic/go-seccomp-bpf v1.6.0
+	github.com/godbus/dbus/v5 v5.2.2
 	golang.org/x/net v0.53.0
 	golang.org/x/sys v0.43.0
 )
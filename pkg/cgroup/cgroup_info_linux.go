@@ -9,7 +9,7 @@ import (
 	"strings"
 )
 
-const numberOfControllers = 5
+const numberOfControllers = 6
 
 // Controllers defines enabled controller of a cgroup
 type Controllers struct {
@@ -18,6 +18,7 @@ type Controllers struct {
 	CPUAcct bool
 	Memory  bool
 	Pids    bool
+	IO      bool
 }
 
 // Set changes the enabled status of a specific controller
@@ -33,6 +34,8 @@ func (c *Controllers) Set(ct string, value bool) {
 		c.Memory = value
 	case Pids:
 		c.Pids = value
+	case IO, BlkIO:
+		c.IO = value
 	}
 }
 
@@ -43,12 +46,13 @@ func (c *Controllers) Intersect(o *Controllers) {
 	c.CPUAcct = c.CPUAcct && o.CPUAcct
 	c.Memory = c.Memory && o.Memory
 	c.Pids = c.Pids && o.Pids
+	c.IO = c.IO && o.IO
 }
 
 // Contains returns true if the current controller enabled all controllers in the other controller
 func (c *Controllers) Contains(o *Controllers) bool {
 	return (c.CPU || !o.CPU) && (c.CPUSet || !o.CPUSet) && (c.CPUAcct || !o.CPUAcct) &&
-		(c.Memory || !o.Memory) && (c.Pids || !o.Pids)
+		(c.Memory || !o.Memory) && (c.Pids || !o.Pids) && (c.IO || !o.IO)
 }
 
 // Names returns a list of string of all enabled container names
@@ -63,6 +67,7 @@ func (c *Controllers) Names() []string {
 		{c.CPUSet, CPUSet},
 		{c.Memory, Memory},
 		{c.Pids, Pids},
+		{c.IO, IO},
 	} {
 		if v.e {
 			names = append(names, v.n)
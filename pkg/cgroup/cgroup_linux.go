@@ -10,7 +10,6 @@ import (
 
 // Cgroup defines the common interface to control cgroups
 // including v1 and v2 implementations.
-// TODO: implement systemd integration
 type Cgroup interface {
 	// AddProc add a process into the cgroup
 	AddProc(pid ...int) error
@@ -27,6 +26,10 @@ type Cgroup interface {
 	// CPUUsage reads total cpu usage of cgroup
 	CPUUsage() (uint64, error)
 
+	// CPUStat reads cpu usage split into user and system together with cpu bandwidth
+	// throttling statistics
+	CPUStat() (CPUStat, error)
+
 	// MemoryUsage reads current total memory usage
 	MemoryUsage() (uint64, error)
 
@@ -36,6 +39,15 @@ type Cgroup interface {
 	// ProcessPeak reads maximum number of process ever existed in cgroup. Not exist in cgroup v1 or kernel < 6.1
 	ProcessPeak() (uint64, error)
 
+	// CPUPressure reads cpu pressure stall information. Not exist in cgroup v1 or kernel < 4.20
+	CPUPressure() (Pressure, error)
+
+	// MemoryPressure reads memory pressure stall information. Not exist in cgroup v1 or kernel < 4.20
+	MemoryPressure() (Pressure, error)
+
+	// IOPressure reads io pressure stall information. Not exist in cgroup v1 or kernel < 4.20
+	IOPressure() (Pressure, error)
+
 	// SetCPUBandwidth sets the cpu bandwidth. Times in ns
 	SetCPUBandwidth(quota, period uint64) error
 
@@ -45,9 +57,43 @@ type Cgroup interface {
 	// SetMemoryLimit sets memory.limit_in_bytes
 	SetMemoryLimit(uint64) error
 
+	// SetMemoryHigh sets memory.high, the throttle limit. Not exist in cgroup v1
+	SetMemoryHigh(uint64) error
+
+	// SetMemoryLow sets memory.low, the best-effort protection (memory.soft_limit_in_bytes on v1)
+	SetMemoryLow(uint64) error
+
+	// SetMemoryMin sets memory.min, the hard protection. Not exist in cgroup v1
+	SetMemoryMin(uint64) error
+
+	// SetMemorySwapMax sets the swap limit (memory.swap.max). On cgroup v1, memory.memsw.limit_in_bytes
+	// is set to the sum of memory limit and swap limit so SetMemoryLimit need to be called before
+	SetMemorySwapMax(uint64) error
+
+	// SetMemoryOOMGroup sets memory.oom.group to kill all processes on OOM. Not exist in cgroup v1
+	SetMemoryOOMGroup(bool) error
+
+	// MemorySwapUsage reads current swap usage
+	MemorySwapUsage() (uint64, error)
+
+	// MemorySwapMaxUsage reads max swap usage. Not exist in cgroup v1 or kernel < 6.5
+	MemorySwapMaxUsage() (uint64, error)
+
+	// MemoryOOMKills reads number of processes killed by the OOM killer. Not exist in cgroup v1 with kernel < 4.13
+	MemoryOOMKills() (uint64, error)
+
 	// SetProcLimit sets pids.max
 	SetProcLimit(uint64) error
 
+	// SetIOMax sets io bandwidth limits per device (io.max / blkio.throttle.*)
+	SetIOMax(...IOMax) error
+
+	// SetIOWeight sets the proportional io weight in range [1, 10000] (io.weight / blkio.weight)
+	SetIOWeight(uint64) error
+
+	// IOStat reads bytes and operations read and written per device
+	IOStat() ([]IOStat, error)
+
 	// Processes lists all existing process pid from the cgroup
 	Processes() ([]int, error)
 
@@ -64,12 +110,14 @@ type Cgroup interface {
 // DetectedCgroupType defines the current cgroup type of the system
 var DetectedCgroupType = DetectType()
 
-// New creates a new cgroup with provided prefix, it opens existing one if existed
-func New(prefix string, ct *Controllers) (Cgroup, error) {
-	if DetectedCgroupType == TypeV1 {
-		return newV1(prefix, ct)
+// New creates a new cgroup with provided prefix, it opens existing one if existed.
+// Optional Options defines the cgroup file system to use
+func New(prefix string, ct *Controllers, opts ...Options) (Cgroup, error) {
+	opt := getOptions(opts)
+	if opt.cgroupType() == TypeV1 {
+		return newV1(opt, prefix, ct)
 	}
-	return newV2(prefix, ct)
+	return newV2(opt, prefix, ct)
 }
 
 func loopV1Controllers(ct *Controllers, v1 *V1, f func(string, **v1controller) error) error {
@@ -83,6 +131,7 @@ func loopV1Controllers(ct *Controllers, v1 *V1, f func(string, **v1controller) e
 		{ct.CPUAcct, CPUAcct, &v1.cpuacct},
 		{ct.Memory, Memory, &v1.memory},
 		{ct.Pids, Pids, &v1.pids},
+		{ct.IO, BlkIO, &v1.blkio},
 	} {
 		if !c.available {
 			continue
@@ -94,21 +143,23 @@ func loopV1Controllers(ct *Controllers, v1 *V1, f func(string, **v1controller) e
 	return nil
 }
 
-func newV1(prefix string, ct *Controllers) (cg Cgroup, err error) {
+func newV1(opt *Options, prefix string, ct *Controllers) (cg Cgroup, err error) {
 	v1 := &V1{
 		prefix: prefix,
+		opt:    opt,
 	}
 	// if failed, remove potential created directory
 	defer func() {
 		if err != nil && !v1.existing {
 			for _, p := range v1.all {
-				remove(p.path)
+				opt.remove(p.path)
 			}
 		}
 	}()
 
 	if err = loopV1Controllers(ct, v1, func(name string, cg **v1controller) error {
-		path, err := CreateV1ControllerPath(name, prefix)
+		path := filepath.Join(opt.root(), name, prefix)
+		err := opt.ensureDirExists(path)
 		*cg = newV1Controller(path)
 		if errors.Is(err, os.ErrExist) {
 			if len(v1.all) == 0 {
@@ -134,17 +185,19 @@ func newV1(prefix string, ct *Controllers) (cg Cgroup, err error) {
 	return v1, err
 }
 
-func newV2(prefix string, ct *Controllers) (cg Cgroup, err error) {
+func newV2(opt *Options, prefix string, ct *Controllers) (cg Cgroup, err error) {
+	root := opt.root()
 	v2 := &V2{
-		path:    filepath.Join(basePath, prefix),
+		path:    filepath.Join(root, prefix),
 		control: ct,
+		opt:     opt,
 	}
 	if _, err := os.Stat(v2.path); err == nil {
 		v2.existing = true
 	}
 	defer func() {
 		if err != nil && !v2.existing {
-			remove(v2.path)
+			opt.remove(v2.path)
 		}
 	}()
 
@@ -159,8 +212,8 @@ func newV2(prefix string, ct *Controllers) (cg Cgroup, err error) {
 		parent := current
 		current = current + "/" + e
 		// try mkdir if not exists
-		if _, err := os.Stat(filepath.Join(basePath, current)); os.IsNotExist(err) {
-			if err := os.Mkdir(filepath.Join(basePath, current), dirPerm); err != nil {
+		if _, err := os.Stat(filepath.Join(root, current)); os.IsNotExist(err) {
+			if err := opt.mkdir(filepath.Join(root, current)); err != nil {
 				return nil, err
 			}
 		} else if err != nil {
@@ -168,36 +221,39 @@ func newV2(prefix string, ct *Controllers) (cg Cgroup, err error) {
 		}
 
 		// no err means create success, need to enable it in its parent folder
-		ect, err := getAvailableControllerV2(current)
+		ect, err := getAvailableControllerV2path(filepath.Join(root, current, cgroupControllers))
 		if err != nil {
 			return nil, err
 		}
 		if ect.Contains(ct) {
 			continue
 		}
-		if err := writeFile(filepath.Join(basePath, parent, cgroupSubtreeControl), controlMsg, filePerm); err != nil {
+		if err := writeFile(filepath.Join(root, parent, cgroupSubtreeControl), controlMsg, filePerm); err != nil {
 			return nil, err
 		}
 	}
 	return v2, nil
 }
 
-// OpenExisting opens a existing cgroup with provided prefix
-func OpenExisting(prefix string, ct *Controllers) (Cgroup, error) {
-	if DetectedCgroupType == TypeV1 {
-		return openExistingV1(prefix, ct)
+// OpenExisting opens a existing cgroup with provided prefix.
+// Optional Options defines the cgroup file system to use
+func OpenExisting(prefix string, ct *Controllers, opts ...Options) (Cgroup, error) {
+	opt := getOptions(opts)
+	if opt.cgroupType() == TypeV1 {
+		return openExistingV1(opt, prefix, ct)
 	}
-	return openExistingV2(prefix, ct)
+	return openExistingV2(opt, prefix, ct)
 }
 
-func openExistingV1(prefix string, ct *Controllers) (cg Cgroup, err error) {
+func openExistingV1(opt *Options, prefix string, ct *Controllers) (cg Cgroup, err error) {
 	v1 := &V1{
 		prefix:   prefix,
 		existing: true,
+		opt:      opt,
 	}
 
 	if err = loopV1Controllers(ct, v1, func(name string, cg **v1controller) error {
-		p := filepath.Join(basePath, name, prefix)
+		p := filepath.Join(opt.root(), name, prefix)
 		*cg = newV1Controller(p)
 		// os.IsNotExist
 		if _, err := os.Stat(p); err != nil {
@@ -215,11 +271,12 @@ func openExistingV1(prefix string, ct *Controllers) (cg Cgroup, err error) {
 			return
 		}
 	}
-	return
+	return v1, nil
 }
 
-func openExistingV2(prefix string, ct *Controllers) (cg Cgroup, err error) {
-	ect, err := getAvailableControllerV2(prefix)
+func openExistingV2(opt *Options, prefix string, ct *Controllers) (cg Cgroup, err error) {
+	path := filepath.Join(opt.root(), prefix)
+	ect, err := getAvailableControllerV2path(filepath.Join(path, cgroupControllers))
 	if err != nil {
 		return nil, err
 	}
@@ -227,8 +284,9 @@ func openExistingV2(prefix string, ct *Controllers) (cg Cgroup, err error) {
 		return nil, fmt.Errorf("open cgroup v2: requesting %v controllers but %v found", ct, ect)
 	}
 	return &V2{
-		path:     filepath.Join(basePath, prefix),
+		path:     path,
 		control:  ect,
 		existing: true,
+		opt:      opt,
 	}, nil
 }
@@ -0,0 +1,241 @@
+// Package cgrouptest provides a fake cgroup file system backed by a temp
+// directory to test code built on cgroup.Cgroup without a delegated cgroup tree.
+package cgrouptest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/criyle/go-sandbox/pkg/cgroup"
+)
+
+const (
+	filePerm = 0644
+	dirPerm  = 0755
+
+	emptyPressure = "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"
+	v1Unlimited   = "9223372036854771712"
+)
+
+// FS is a fake cgroup file system that populates control files with their
+// initial values when a cgroup directory is created. Values written into
+// control files are kept as-is and counters never change unless set by Set
+// (e.g. writing "reset" into memory.peak does not reset it)
+type FS struct {
+	Root        string
+	Type        cgroup.Type
+	Controllers *cgroup.Controllers
+}
+
+// New creates a fake cgroup file system of the type in dir with the
+// controllers available
+func New(dir string, t cgroup.Type, ct *cgroup.Controllers) (*FS, error) {
+	f := &FS{
+		Root:        dir,
+		Type:        t,
+		Controllers: ct,
+	}
+	if t == cgroup.TypeV1 {
+		for _, name := range ct.Names() {
+			if name == cgroup.IO {
+				name = cgroup.BlkIO
+			}
+			if err := f.mkdir(filepath.Join(dir, name), dirPerm); err != nil {
+				return nil, err
+			}
+		}
+		// root cpuset is initialized
+		if ct.CPUSet {
+			for _, n := range []string{"cpuset.cpus", "cpuset.mems"} {
+				if err := f.Set("", n, "0\n"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return f, nil
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, err
+	}
+	if err := f.populate(dir, map[string]string{
+		"cgroup.controllers": strings.Join(ct.Names(), " ") + "\n",
+	}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Options returns the options to create cgroups inside the fake file system
+func (f *FS) Options() cgroup.Options {
+	return cgroup.Options{
+		Root:  f.Root,
+		Type:  f.Type,
+		Mkdir: f.mkdir,
+		Rmdir: f.rmdir,
+	}
+}
+
+// Set writes content into the control file of the cgroup with prefix, the
+// controller directory is chosen by the file name on v1
+func (f *FS) Set(prefix, name, content string) error {
+	return os.WriteFile(f.path(prefix, name), []byte(content), filePerm)
+}
+
+// Get reads content of the control file of the cgroup with prefix
+func (f *FS) Get(prefix, name string) (string, error) {
+	b, err := os.ReadFile(f.path(prefix, name))
+	return string(b), err
+}
+
+func (f *FS) path(prefix, name string) string {
+	if f.Type != cgroup.TypeV1 {
+		return filepath.Join(f.Root, prefix, name)
+	}
+	ct, _, _ := strings.Cut(name, ".")
+	return filepath.Join(f.Root, ct, prefix, name)
+}
+
+func (f *FS) mkdir(path string, perm os.FileMode) error {
+	if err := os.Mkdir(path, perm); err != nil {
+		return err
+	}
+	if f.Type == cgroup.TypeV1 {
+		rel, err := filepath.Rel(f.Root, path)
+		if err != nil {
+			return err
+		}
+		ct, _, _ := strings.Cut(rel, string(filepath.Separator))
+		return f.populate(path, v1Files(ct))
+	}
+	// all controllers are available to sub-cgroups
+	ct, err := os.ReadFile(filepath.Join(filepath.Dir(path), "cgroup.controllers"))
+	if err != nil {
+		return err
+	}
+	files := v2Files(f.Controllers)
+	files["cgroup.controllers"] = string(ct)
+	return f.populate(path, files)
+}
+
+// rmdir removes the cgroup directory with its control files, it fails with
+// EBUSY if sub-cgroups exist
+func (f *FS) rmdir(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			return &os.PathError{Op: "rmdir", Path: path, Err: syscall.EBUSY}
+		}
+	}
+	return os.RemoveAll(path)
+}
+
+func (f *FS) populate(path string, files map[string]string) error {
+	for n, c := range files {
+		if err := os.WriteFile(filepath.Join(path, n), []byte(c), filePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func v2Files(ct *cgroup.Controllers) map[string]string {
+	files := map[string]string{
+		"cgroup.procs":           "",
+		"cgroup.subtree_control": "",
+		"cpu.stat":               "usage_usec 0\nuser_usec 0\nsystem_usec 0\n",
+		"cpu.pressure":           emptyPressure,
+		"memory.pressure":        emptyPressure,
+		"io.pressure":            emptyPressure,
+	}
+	if ct.CPU {
+		files["cpu.stat"] += "nr_periods 0\nnr_throttled 0\nthrottled_usec 0\n"
+		files["cpu.max"] = "max 100000\n"
+		files["cpu.weight"] = "100\n"
+	}
+	if ct.CPUSet {
+		files["cpuset.cpus"] = ""
+		files["cpuset.mems"] = ""
+	}
+	if ct.Memory {
+		for n, c := range map[string]string{
+			"memory.current":      "0\n",
+			"memory.peak":         "0\n",
+			"memory.max":          "max\n",
+			"memory.high":         "max\n",
+			"memory.low":          "0\n",
+			"memory.min":          "0\n",
+			"memory.swap.current": "0\n",
+			"memory.swap.peak":    "0\n",
+			"memory.swap.max":     "max\n",
+			"memory.oom.group":    "0\n",
+			"memory.stat":         "",
+			"memory.events":       "low 0\nhigh 0\nmax 0\noom 0\noom_kill 0\n",
+		} {
+			files[n] = c
+		}
+	}
+	if ct.Pids {
+		files["pids.current"] = "0\n"
+		files["pids.peak"] = "0\n"
+		files["pids.max"] = "max\n"
+	}
+	if ct.IO {
+		files["io.stat"] = ""
+		files["io.max"] = ""
+		files["io.weight"] = "default 100\n"
+	}
+	return files
+}
+
+func v1Files(ct string) map[string]string {
+	files := map[string]string{
+		"cgroup.procs": "",
+	}
+	switch ct {
+	case cgroup.CPU:
+		files["cpu.cfs_period_us"] = "100000\n"
+		files["cpu.cfs_quota_us"] = "-1\n"
+		files["cpu.shares"] = "1024\n"
+		files["cpu.stat"] = "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n"
+
+	case cgroup.CPUAcct:
+		files["cpuacct.usage"] = "0\n"
+		files["cpuacct.usage_user"] = "0\n"
+		files["cpuacct.usage_sys"] = "0\n"
+		files["cpuacct.stat"] = "user 0\nsystem 0\n"
+
+	case cgroup.CPUSet:
+		files["cpuset.cpus"] = ""
+		files["cpuset.mems"] = ""
+
+	case cgroup.Memory:
+		files["memory.usage_in_bytes"] = "0\n"
+		files["memory.max_usage_in_bytes"] = "0\n"
+		files["memory.limit_in_bytes"] = v1Unlimited + "\n"
+		files["memory.soft_limit_in_bytes"] = v1Unlimited + "\n"
+		files["memory.memsw.usage_in_bytes"] = "0\n"
+		files["memory.memsw.max_usage_in_bytes"] = "0\n"
+		files["memory.memsw.limit_in_bytes"] = v1Unlimited + "\n"
+		files["memory.stat"] = ""
+		files["memory.oom_control"] = "oom_kill_disable 0\nunder_oom 0\noom_kill 0\n"
+
+	case cgroup.Pids:
+		files["pids.current"] = "0\n"
+		files["pids.max"] = "max\n"
+
+	case cgroup.BlkIO:
+		files["blkio.weight"] = "500\n"
+		files["blkio.throttle.io_service_bytes"] = "Total 0\n"
+		files["blkio.throttle.io_serviced"] = "Total 0\n"
+		files["blkio.throttle.read_bps_device"] = ""
+		files["blkio.throttle.write_bps_device"] = ""
+		files["blkio.throttle.read_iops_device"] = ""
+		files["blkio.throttle.write_iops_device"] = ""
+	}
+	return files
+}
@@ -0,0 +1,146 @@
+package cgrouptest
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/criyle/go-sandbox/pkg/cgroup"
+)
+
+var allControllers = &cgroup.Controllers{
+	CPU:     true,
+	CPUSet:  true,
+	CPUAcct: true,
+	Memory:  true,
+	Pids:    true,
+	IO:      true,
+}
+
+func TestFakeCgroup(t *testing.T) {
+	for _, tp := range []cgroup.Type{cgroup.TypeV1, cgroup.TypeV2} {
+		t.Run(tp.String(), func(t *testing.T) {
+			f, err := New(t.TempDir(), tp, allControllers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cg, err := cgroup.New("fake_test", allControllers, f.Options())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cg.SetMemoryLimit(1 << 20); err != nil {
+				t.Fatal(err)
+			}
+			if err := cg.SetProcLimit(4); err != nil {
+				t.Fatal(err)
+			}
+			if err := cg.SetCPUBandwidth(50000, 100000); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Set("fake_test", usageFile(tp), "1024\n"); err != nil {
+				t.Fatal(err)
+			}
+			if u, err := cg.MemoryMaxUsage(); err != nil || u != 1024 {
+				t.Errorf("expected max usage 1024, got %d, %v", u, err)
+			}
+			if n, err := cg.MemoryOOMKills(); err != nil || n != 0 {
+				t.Errorf("expected no oom kill, got %d, %v", n, err)
+			}
+			if err := f.Set("fake_test", oomFile(tp), "oom_kill_disable 0\nunder_oom 0\noom 1\noom_kill 1\n"); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := cg.MemoryOOMKills(); err != nil || n != 1 {
+				t.Errorf("expected 1 oom kill, got %d, %v", n, err)
+			}
+			if _, err := cg.CPUStat(); err != nil {
+				t.Error(err)
+			}
+			if _, err := cg.IOStat(); err != nil {
+				t.Error(err)
+			}
+			if tp == cgroup.TypeV2 {
+				if _, err := cg.CPUPressure(); err != nil {
+					t.Error(err)
+				}
+			}
+			if v, err := f.Get("fake_test", "pids.max"); err != nil || strings.TrimSpace(v) != "4" {
+				t.Errorf("expected pids.max 4, got %q, %v", v, err)
+			}
+
+			sub, err := cg.Random("sub_*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = cg.Destroy()
+			if !errors.Is(err, syscall.EBUSY) {
+				t.Errorf("expected EBUSY with sub-cgroup, got %v", err)
+			}
+			if err := sub.Destroy(); err != nil {
+				t.Fatal(err)
+			}
+
+			ex, err := cgroup.OpenExisting("fake_test", allControllers, f.Options())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ex.Existing() {
+				t.Errorf("expected existing cgroup")
+			}
+			// existing cgroups are kept on destroy
+			if err := ex.Destroy(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Get("fake_test", "pids.max"); err != nil {
+				t.Errorf("expected existing cgroup to be kept, got %v", err)
+			}
+			if err := cg.Destroy(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Get("fake_test", "pids.max"); !os.IsNotExist(err) {
+				t.Errorf("expected cgroup to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFakePool(t *testing.T) {
+	f, err := New(t.TempDir(), cgroup.TypeV2, allControllers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := cgroup.New("pool_test", allControllers, f.Options())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.Destroy()
+
+	p, err := cgroup.NewPool(parent, "pool", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Destroy()
+
+	cg, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cg.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func usageFile(t cgroup.Type) string {
+	if t == cgroup.TypeV1 {
+		return "memory.max_usage_in_bytes"
+	}
+	return "memory.peak"
+}
+
+func oomFile(t cgroup.Type) string {
+	if t == cgroup.TypeV1 {
+		return "memory.oom_control"
+	}
+	return "memory.events"
+}
@@ -0,0 +1,341 @@
+package cgrouptest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName        = "org.freedesktop.DBus"
+	propertiesName = "org.freedesktop.DBus.Properties"
+	managerName    = "org.freedesktop.systemd1.Manager"
+	managerPath    = "/org/freedesktop/systemd1"
+	defaultSlice   = "system.slice"
+	serverGUID     = "0123456789abcdef0123456789abcdef"
+)
+
+// Systemd is a fake systemd manager serving a local D-Bus endpoint. It
+// creates transient scopes inside the fake cgroup file system
+type Systemd struct {
+	// Address is the D-Bus address of the endpoint
+	Address string
+
+	fs *FS
+	l  net.Listener
+
+	mu     sync.Mutex
+	units  map[string]*Unit
+	serial uint32
+	jobs   uint32
+	conns  int
+}
+
+// Unit is a transient unit started by the fake systemd
+type Unit struct {
+	Name         string
+	ControlGroup string
+	Properties   map[string]any
+}
+
+// NewSystemd starts a fake systemd D-Bus endpoint listening on the unix socket
+// path that creates scopes inside the fake cgroup file system
+func (f *FS) NewSystemd(socket string) (*Systemd, error) {
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	s := &Systemd{
+		Address: "unix:path=" + socket,
+		fs:      f,
+		l:       l,
+		units:   make(map[string]*Unit),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the endpoint
+func (s *Systemd) Close() error {
+	return s.l.Close()
+}
+
+// Unit returns the unit started with name
+func (s *Systemd) Unit(name string) (*Unit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.units[name]
+	return u, ok
+}
+
+func (s *Systemd) serve() {
+	for {
+		c, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer c.Close()
+			s.handleConn(c)
+		}()
+	}
+}
+
+func (s *Systemd) handleConn(c net.Conn) {
+	r := bufio.NewReader(c)
+	if err := authenticate(r, c); err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.conns++
+	name := ":1." + strconv.Itoa(s.conns)
+	s.mu.Unlock()
+
+	for {
+		msg, err := dbus.DecodeMessage(r)
+		if err != nil {
+			return
+		}
+		if msg.Type != dbus.TypeMethodCall {
+			continue
+		}
+		for _, m := range s.handleCall(name, msg) {
+			if err := s.send(c, m); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// authenticate runs the server side of the SASL handshake, accepting any
+// EXTERNAL credential
+func authenticate(r *bufio.Reader, w io.Writer) error {
+	if b, err := r.ReadByte(); err != nil || b != 0 {
+		return errors.New("auth: missing null byte")
+	}
+	for {
+		l, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		var reply string
+		switch f := strings.Fields(l); {
+		case len(f) == 0:
+			reply = "ERROR"
+		case f[0] == "AUTH" && len(f) > 1 && f[1] == "EXTERNAL":
+			reply = "OK " + serverGUID
+		case f[0] == "AUTH":
+			reply = "REJECTED EXTERNAL"
+		case f[0] == "NEGOTIATE_UNIX_FD":
+			reply = "AGREE_UNIX_FD"
+		case f[0] == "BEGIN":
+			return nil
+		default:
+			reply = "ERROR"
+		}
+		if _, err := io.WriteString(w, reply+"\r\n"); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Systemd) handleCall(name string, msg *dbus.Message) []*dbus.Message {
+	iface, _ := msg.Headers[dbus.FieldInterface].Value().(string)
+	member, _ := msg.Headers[dbus.FieldMember].Value().(string)
+	path, _ := msg.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)
+
+	switch iface + "." + member {
+	case busName + ".Hello":
+		return []*dbus.Message{reply(msg, name)}
+
+	case busName + ".AddMatch", busName + ".RemoveMatch", managerName + ".Subscribe":
+		return []*dbus.Message{reply(msg)}
+
+	case managerName + ".StartTransientUnit":
+		var (
+			unit, mode string
+			props      []struct {
+				Name  string
+				Value dbus.Variant
+			}
+			aux []struct {
+				Name  string
+				Props []struct {
+					Name  string
+					Value dbus.Variant
+				}
+			}
+		)
+		if err := dbus.Store(msg.Body, &unit, &mode, &props, &aux); err != nil {
+			return []*dbus.Message{replyError(msg, "org.freedesktop.DBus.Error.InvalidArgs", err)}
+		}
+		p := make(map[string]any, len(props))
+		for _, v := range props {
+			p[v.Name] = v.Value.Value()
+		}
+		job, err := s.startScope(unit, p)
+		if err != nil {
+			return []*dbus.Message{replyError(msg, "org.freedesktop.systemd1.UnitExists", err)}
+		}
+		removed := &dbus.Message{
+			Type: dbus.TypeSignal,
+			Headers: map[dbus.HeaderField]dbus.Variant{
+				dbus.FieldPath:      dbus.MakeVariant(dbus.ObjectPath(managerPath)),
+				dbus.FieldInterface: dbus.MakeVariant(managerName),
+				dbus.FieldMember:    dbus.MakeVariant("JobRemoved"),
+			},
+			Body: []any{job, jobPath(job), unit, "done"},
+		}
+		removed.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(removed.Body...))
+		return []*dbus.Message{reply(msg, jobPath(job)), removed}
+
+	case managerName + ".GetUnit":
+		var unit string
+		if err := dbus.Store(msg.Body, &unit); err != nil {
+			return []*dbus.Message{replyError(msg, "org.freedesktop.DBus.Error.InvalidArgs", err)}
+		}
+		if _, ok := s.Unit(unit); !ok {
+			return []*dbus.Message{replyError(msg, "org.freedesktop.systemd1.NoSuchUnit", fmt.Errorf("unit %s not loaded", unit))}
+		}
+		return []*dbus.Message{reply(msg, unitPath(unit))}
+
+	case propertiesName + ".Get":
+		var i, prop string
+		if err := dbus.Store(msg.Body, &i, &prop); err != nil {
+			return []*dbus.Message{replyError(msg, "org.freedesktop.DBus.Error.InvalidArgs", err)}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, u := range s.units {
+			if unitPath(u.Name) == path && prop == "ControlGroup" {
+				return []*dbus.Message{reply(msg, dbus.MakeVariant(u.ControlGroup))}
+			}
+		}
+		return []*dbus.Message{replyError(msg, "org.freedesktop.DBus.Error.UnknownProperty", fmt.Errorf("unknown property %s", prop))}
+	}
+	return []*dbus.Message{replyError(msg, "org.freedesktop.DBus.Error.UnknownMethod", fmt.Errorf("unknown method %s.%s", iface, member))}
+}
+
+// startScope creates the scope cgroup under its slice and moves the pids into it
+func (s *Systemd) startScope(unit string, props map[string]any) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.units[unit]; ok {
+		return 0, fmt.Errorf("unit %s already exists", unit)
+	}
+	slice, _ := props["Slice"].(string)
+	if slice == "" {
+		slice = defaultSlice
+	}
+	cg := "/" + filepath.Join(expandSlice(slice), unit)
+	if err := s.mkdirAll(cg); err != nil {
+		return 0, err
+	}
+	pids, _ := props["PIDs"].([]uint32)
+	var procs strings.Builder
+	for _, p := range pids {
+		fmt.Fprintln(&procs, p)
+	}
+	if err := s.fs.Set(cg, "cgroup.procs", procs.String()); err != nil {
+		return 0, err
+	}
+	s.units[unit] = &Unit{
+		Name:         unit,
+		ControlGroup: cg,
+		Properties:   props,
+	}
+	s.jobs++
+	return s.jobs, nil
+}
+
+func (s *Systemd) mkdirAll(cg string) error {
+	p := s.fs.Root
+	for _, e := range strings.Split(strings.Trim(cg, "/"), "/") {
+		p = filepath.Join(p, e)
+		if _, err := os.Stat(p); err == nil {
+			continue
+		}
+		if err := s.fs.mkdir(p, dirPerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send encodes the message with the next serial
+func (s *Systemd) send(w io.Writer, msg *dbus.Message) error {
+	var buf bytes.Buffer
+	if err := msg.EncodeTo(&buf, binary.LittleEndian); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.serial++
+	binary.LittleEndian.PutUint32(buf.Bytes()[8:12], s.serial)
+	s.mu.Unlock()
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func reply(call *dbus.Message, body ...any) *dbus.Message {
+	msg := &dbus.Message{
+		Type: dbus.TypeMethodReply,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldReplySerial: dbus.MakeVariant(call.Serial()),
+		},
+		Body: body,
+	}
+	if len(body) > 0 {
+		msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(body...))
+	}
+	return msg
+}
+
+func replyError(call *dbus.Message, name string, err error) *dbus.Message {
+	msg := reply(call, err.Error())
+	msg.Type = dbus.TypeError
+	msg.Headers[dbus.FieldErrorName] = dbus.MakeVariant(name)
+	return msg
+}
+
+// expandSlice returns the cgroup path of slice (e.g. a-b.slice -> a.slice/a-b.slice)
+func expandSlice(slice string) string {
+	name := strings.TrimSuffix(slice, ".slice")
+	if name == "-" || name == "" {
+		return ""
+	}
+	var path []string
+	prefix := ""
+	for _, p := range strings.Split(name, "-") {
+		prefix += p
+		path = append(path, prefix+".slice")
+		prefix += "-"
+	}
+	return filepath.Join(path...)
+}
+
+func jobPath(id uint32) dbus.ObjectPath {
+	return dbus.ObjectPath(managerPath + "/job/" + strconv.FormatUint(uint64(id), 10))
+}
+
+func unitPath(unit string) dbus.ObjectPath {
+	var b strings.Builder
+	for _, c := range []byte(unit) {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return dbus.ObjectPath(managerPath + "/unit/" + b.String())
+}
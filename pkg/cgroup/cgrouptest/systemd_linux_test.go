@@ -0,0 +1,71 @@
+package cgrouptest
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/criyle/go-sandbox/pkg/cgroup"
+)
+
+func TestSystemd(t *testing.T) {
+	f, err := New(t.TempDir(), cgroup.TypeV2, allControllers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := f.NewSystemd(filepath.Join(t.TempDir(), "bus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sd.Close()
+
+	opt := cgroup.SystemdOptions{
+		Options: f.Options(),
+		Address: sd.Address,
+		Slice:   "runprog-test.slice",
+	}
+	cg, err := cgroup.NewSystemd("runprog-test", allControllers, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, ok := sd.Unit("runprog-test.scope")
+	if !ok {
+		t.Fatal("expected scope to be started")
+	}
+	if u.ControlGroup != "/runprog.slice/runprog-test.slice/runprog-test.scope" {
+		t.Errorf("unexpected control group %q", u.ControlGroup)
+	}
+	if d, _ := u.Properties["Delegate"].(bool); !d {
+		t.Errorf("expected Delegate=yes")
+	}
+
+	// current process moved into the init cgroup
+	procs, err := f.Get(filepath.Join(u.ControlGroup, "init"), "cgroup.procs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(procs) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("expected current process in init cgroup, got %q", procs)
+	}
+	sub, err := cg.Random("sub_*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cgroup.NewSystemd("runprog-test.scope", allControllers, opt); err == nil {
+		t.Errorf("expected error for existing unit")
+	}
+}
+
+func TestSystemdV1(t *testing.T) {
+	if _, err := cgroup.NewSystemd("runprog-test", allControllers, cgroup.SystemdOptions{
+		Options: cgroup.Options{Type: cgroup.TypeV1},
+	}); err == nil {
+		t.Errorf("expected error on cgroup v1")
+	}
+}
@@ -19,6 +19,8 @@ const (
 	CPUSet  = "cpuset"
 	Memory  = "memory"
 	Pids    = "pids"
+	IO      = "io"
+	BlkIO   = "blkio" // io controller on v1
 )
 
 // Type defines the version of cgroup
@@ -0,0 +1,133 @@
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CPUStat defines cpu accounting and bandwidth throttling statistics of a cgroup
+type CPUStat struct {
+	Usage  time.Duration // total cpu time
+	User   time.Duration // user cpu time
+	System time.Duration // system cpu time
+
+	NrPeriods     uint64        // number of enforcement periods elapsed
+	NrThrottled   uint64        // number of periods the cgroup was throttled
+	ThrottledTime time.Duration // total time the cgroup was throttled
+}
+
+// userHZ is the unit of cpuacct.stat (USER_HZ)
+const userHZ = 100
+
+// CPUStat reads cpu.stat. Throttling statistics are zero if cpu controller is
+// not enabled
+func (c *V2) CPUStat() (CPUStat, error) {
+	b, err := c.ReadFile("cpu.stat")
+	if err != nil {
+		return CPUStat{}, err
+	}
+	m, err := parseFlatKeyed(b)
+	if err != nil {
+		return CPUStat{}, err
+	}
+	return CPUStat{
+		Usage:         time.Duration(m["usage_usec"]) * time.Microsecond,
+		User:          time.Duration(m["user_usec"]) * time.Microsecond,
+		System:        time.Duration(m["system_usec"]) * time.Microsecond,
+		NrPeriods:     m["nr_periods"],
+		NrThrottled:   m["nr_throttled"],
+		ThrottledTime: time.Duration(m["throttled_usec"]) * time.Microsecond,
+	}, nil
+}
+
+// CPUStat reads cpuacct.usage, cpuacct.usage_user, cpuacct.usage_sys
+// (cpuacct.stat on kernel < 4.7) and cpu.stat
+func (c *V1) CPUStat() (CPUStat, error) {
+	var st CPUStat
+	usage, err := c.cpuacct.ReadUint("cpuacct.usage")
+	if err != nil {
+		return st, err
+	}
+	st.Usage = time.Duration(usage)
+
+	user, err := c.cpuacct.ReadUint("cpuacct.usage_user")
+	if err == nil {
+		st.User = time.Duration(user)
+		sys, err := c.cpuacct.ReadUint("cpuacct.usage_sys")
+		if err != nil {
+			return st, err
+		}
+		st.System = time.Duration(sys)
+	} else if errors.Is(err, os.ErrNotExist) {
+		b, err := c.cpuacct.ReadFile("cpuacct.stat")
+		if err != nil {
+			return st, err
+		}
+		m, err := parseFlatKeyed(b)
+		if err != nil {
+			return st, err
+		}
+		st.User = time.Duration(m["user"]) * time.Second / userHZ
+		st.System = time.Duration(m["system"]) * time.Second / userHZ
+	} else {
+		return st, err
+	}
+
+	// cpu controller may not be enabled
+	if c.cpu == nil {
+		return st, nil
+	}
+	b, err := c.cpu.ReadFile("cpu.stat")
+	if err != nil {
+		return st, err
+	}
+	m, err := parseFlatKeyed(b)
+	if err != nil {
+		return st, err
+	}
+	st.NrPeriods = m["nr_periods"]
+	st.NrThrottled = m["nr_throttled"]
+	st.ThrottledTime = time.Duration(m["throttled_time"])
+	return st, nil
+}
+
+// readFlatKey reads the value of key from the flat keyed file name
+func readFlatKey(read func(string) ([]byte, error), name, key string) (uint64, error) {
+	b, err := read(name)
+	if err != nil {
+		return 0, err
+	}
+	m, err := parseFlatKeyed(b)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := m[key]
+	if !ok {
+		return 0, fmt.Errorf("%s: %s: %w", name, key, os.ErrNotExist)
+	}
+	return v, nil
+}
+
+// parseFlatKeyed parses cgroup flat keyed file in format of "key value" per line
+func parseFlatKeyed(b []byte) (map[string]uint64, error) {
+	m := make(map[string]uint64)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		parts := strings.Fields(s.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		m[parts[0]] = v
+	}
+	return m, s.Err()
+}
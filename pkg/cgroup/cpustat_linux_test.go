@@ -0,0 +1,66 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestV2CPUStat(t *testing.T) {
+	dir := t.TempDir()
+	content := `usage_usec 3000
+user_usec 2000
+system_usec 1000
+nr_periods 10
+nr_throttled 4
+throttled_usec 500
+`
+	if err := os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte(content), filePerm); err != nil {
+		t.Fatal(err)
+	}
+	st, err := (&V2{path: dir, control: &Controllers{}}).CPUStat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CPUStat{
+		Usage:         3 * time.Millisecond,
+		User:          2 * time.Millisecond,
+		System:        time.Millisecond,
+		NrPeriods:     10,
+		NrThrottled:   4,
+		ThrottledTime: 500 * time.Microsecond,
+	}
+	if st != want {
+		t.Errorf("expected %+v, got %+v", want, st)
+	}
+}
+
+func TestV1CPUStat(t *testing.T) {
+	cpu, cpuacct := t.TempDir(), t.TempDir()
+	for f, c := range map[string]string{
+		filepath.Join(cpuacct, "cpuacct.usage"): "3000000\n",
+		filepath.Join(cpuacct, "cpuacct.stat"):  "user 200\nsystem 100\n",
+		filepath.Join(cpu, "cpu.stat"):          "nr_periods 10\nnr_throttled 4\nthrottled_time 500\n",
+	} {
+		if err := os.WriteFile(f, []byte(c), filePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v1 := &V1{cpu: newV1Controller(cpu), cpuacct: newV1Controller(cpuacct)}
+	st, err := v1.CPUStat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CPUStat{
+		Usage:         3 * time.Millisecond,
+		User:          2 * time.Second,
+		System:        time.Second,
+		NrPeriods:     10,
+		NrThrottled:   4,
+		ThrottledTime: 500,
+	}
+	if st != want {
+		t.Errorf("expected %+v, got %+v", want, st)
+	}
+}
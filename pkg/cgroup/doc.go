@@ -9,6 +9,11 @@
 //	cpuacct
 //	memory
 //	pids
+//	io (blkio on v1)
 //
-// Current not available: devices, freezer, net_cls, blkio, perf_event, net_prio, huge_tlb, rdma
+// Current not available: devices, freezer, net_cls, perf_event, net_prio, huge_tlb, rdma
+//
+// On systemd hosts, NewSystemd asks systemd over D-Bus for a transient scope
+// with delegation so that sub-cgroups are created inside the delegated scope
+// rather than the cgroup of the service.
 package cgroup
@@ -0,0 +1,239 @@
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IOMax defines io bandwidth limit of a block device, zero means no limit
+type IOMax struct {
+	Major, Minor uint32
+
+	RBps  uint64 // read bytes per second
+	WBps  uint64 // write bytes per second
+	RIOps uint64 // read operations per second
+	WIOps uint64 // write operations per second
+}
+
+// IOStat defines io statistics of a block device
+type IOStat struct {
+	Major, Minor uint32
+
+	RBytes uint64 // bytes read
+	WBytes uint64 // bytes written
+	RIOs   uint64 // read operations
+	WIOs   uint64 // write operations
+}
+
+// SetIOMax sets io.max
+func (c *V2) SetIOMax(limits ...IOMax) error {
+	if !c.control.IO {
+		return ErrNotInitialized
+	}
+	for _, l := range limits {
+		content := fmt.Sprintf("%d:%d rbps=%s wbps=%s riops=%s wiops=%s", l.Major, l.Minor,
+			ioMaxValue(l.RBps), ioMaxValue(l.WBps), ioMaxValue(l.RIOps), ioMaxValue(l.WIOps))
+		if err := c.WriteFile("io.max", []byte(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetIOWeight sets io.weight
+func (c *V2) SetIOWeight(w uint64) error {
+	if !c.control.IO {
+		return ErrNotInitialized
+	}
+	return c.WriteFile("io.weight", []byte("default "+strconv.FormatUint(w, 10)))
+}
+
+// IOStat reads io.stat
+func (c *V2) IOStat() ([]IOStat, error) {
+	if !c.control.IO {
+		return nil, ErrNotInitialized
+	}
+	b, err := c.ReadFile("io.stat")
+	if err != nil {
+		return nil, err
+	}
+	return parseIOStatV2(b)
+}
+
+// SetIOMax sets blkio.throttle.read_bps_device, blkio.throttle.write_bps_device,
+// blkio.throttle.read_iops_device and blkio.throttle.write_iops_device
+func (c *V1) SetIOMax(limits ...IOMax) error {
+	if c.blkio == nil {
+		return ErrNotInitialized
+	}
+	for _, l := range limits {
+		for _, v := range []struct {
+			name  string
+			value uint64
+		}{
+			{"blkio.throttle.read_bps_device", l.RBps},
+			{"blkio.throttle.write_bps_device", l.WBps},
+			{"blkio.throttle.read_iops_device", l.RIOps},
+			{"blkio.throttle.write_iops_device", l.WIOps},
+		} {
+			content := fmt.Sprintf("%d:%d %d", l.Major, l.Minor, v.value)
+			if err := c.blkio.WriteFile(v.name, []byte(content)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetIOWeight sets blkio.weight, weight is converted from range [1, 10000] to [10, 1000]
+func (c *V1) SetIOWeight(w uint64) error {
+	if c.blkio == nil {
+		return ErrNotInitialized
+	}
+	return c.blkio.WriteUint("blkio.weight", ioWeightToBlkIO(w))
+}
+
+// IOStat reads blkio.throttle.io_service_bytes and blkio.throttle.io_serviced
+func (c *V1) IOStat() ([]IOStat, error) {
+	if c.blkio == nil {
+		return nil, ErrNotInitialized
+	}
+	b, err := c.blkio.ReadFile("blkio.throttle.io_service_bytes")
+	if err != nil {
+		return nil, err
+	}
+	ios, err := c.blkio.ReadFile("blkio.throttle.io_serviced")
+	if err != nil {
+		return nil, err
+	}
+	return parseIOStatV1(b, ios)
+}
+
+func ioMaxValue(v uint64) string {
+	if v == 0 {
+		return "max"
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+// ioWeightToBlkIO converts io.weight [1, 10000] to blkio.weight [10, 1000]
+func ioWeightToBlkIO(w uint64) uint64 {
+	w = min(max(w, 1), 10000)
+	return 10 + (w-1)*990/9999
+}
+
+func parseDevice(s string) (uint32, uint32, error) {
+	ma, mi, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("io: invalid device %q", s)
+	}
+	major, err := strconv.ParseUint(ma, 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	minor, err := strconv.ParseUint(mi, 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(major), uint32(minor), nil
+}
+
+// parseIOStatV2 parses io.stat in format of
+//
+//	8:0 rbytes=90112 wbytes=0 rios=3 wios=0 dbytes=0 dios=0
+func parseIOStatV2(b []byte) ([]IOStat, error) {
+	var rt []IOStat
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		parts := strings.Fields(s.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		var st IOStat
+		var err error
+		if st.Major, st.Minor, err = parseDevice(parts[0]); err != nil {
+			return nil, err
+		}
+		for _, f := range parts[1:] {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				continue
+			}
+			var p *uint64
+			switch k {
+			case "rbytes":
+				p = &st.RBytes
+			case "wbytes":
+				p = &st.WBytes
+			case "rios":
+				p = &st.RIOs
+			case "wios":
+				p = &st.WIOs
+			default:
+				continue
+			}
+			if *p, err = strconv.ParseUint(v, 10, 64); err != nil {
+				return nil, err
+			}
+		}
+		rt = append(rt, st)
+	}
+	return rt, s.Err()
+}
+
+// parseIOStatV1 parses blkio.throttle.io_service_bytes and blkio.throttle.io_serviced
+// in format of
+//
+//	8:0 Read 90112
+//	8:0 Write 0
+//	Total 90112
+func parseIOStatV1(bytesContent, iosContent []byte) ([]IOStat, error) {
+	var rt []IOStat
+	index := make(map[[2]uint32]int)
+	for _, v := range []struct {
+		content     []byte
+		read, write func(*IOStat) *uint64
+	}{
+		{bytesContent, func(s *IOStat) *uint64 { return &s.RBytes }, func(s *IOStat) *uint64 { return &s.WBytes }},
+		{iosContent, func(s *IOStat) *uint64 { return &s.RIOs }, func(s *IOStat) *uint64 { return &s.WIOs }},
+	} {
+		s := bufio.NewScanner(bytes.NewReader(v.content))
+		for s.Scan() {
+			parts := strings.Fields(s.Text())
+			if len(parts) != 3 {
+				continue
+			}
+			major, minor, err := parseDevice(parts[0])
+			if err != nil {
+				return nil, err
+			}
+			var field func(*IOStat) *uint64
+			switch parts[1] {
+			case "Read":
+				field = v.read
+			case "Write":
+				field = v.write
+			default:
+				continue
+			}
+			n, err := strconv.ParseUint(parts[2], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			i, ok := index[[2]uint32{major, minor}]
+			if !ok {
+				i = len(rt)
+				index[[2]uint32{major, minor}] = i
+				rt = append(rt, IOStat{Major: major, Minor: minor})
+			}
+			*field(&rt[i]) = n
+		}
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return rt, nil
+}
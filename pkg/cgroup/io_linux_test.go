@@ -0,0 +1,77 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIOStatV2(t *testing.T) {
+	st, err := parseIOStatV2([]byte("8:0 rbytes=90112 wbytes=4096 rios=3 wios=1 dbytes=0 dios=0\n259:0 rbytes=1 wbytes=2 rios=3 wios=4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []IOStat{
+		{Major: 8, Minor: 0, RBytes: 90112, WBytes: 4096, RIOs: 3, WIOs: 1},
+		{Major: 259, Minor: 0, RBytes: 1, WBytes: 2, RIOs: 3, WIOs: 4},
+	}
+	if len(st) != len(want) {
+		t.Fatalf("expected %d devices, got %d", len(want), len(st))
+	}
+	for i := range want {
+		if st[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], st[i])
+		}
+	}
+}
+
+func TestParseIOStatV1(t *testing.T) {
+	st, err := parseIOStatV1(
+		[]byte("8:0 Read 90112\n8:0 Write 4096\n8:0 Sync 0\n8:0 Total 94208\nTotal 94208\n"),
+		[]byte("8:0 Read 3\n8:0 Write 1\n8:0 Total 4\nTotal 4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IOStat{Major: 8, Minor: 0, RBytes: 90112, WBytes: 4096, RIOs: 3, WIOs: 1}
+	if len(st) != 1 || st[0] != want {
+		t.Errorf("expected %+v, got %+v", want, st)
+	}
+}
+
+func TestV2SetIOMax(t *testing.T) {
+	dir := t.TempDir()
+	c := &V2{path: dir, control: &Controllers{IO: true}}
+	if err := c.SetIOMax(IOMax{Major: 8, Minor: 0, WBps: 1 << 20}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "io.max"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "8:0 rbps=max wbps=1048576 riops=max wiops=max"; string(b) != want {
+		t.Errorf("expected %q, got %q", want, b)
+	}
+	if err := (&V2{path: dir, control: &Controllers{}}).SetIOWeight(100); err != ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+}
+
+func TestV1IONotInitialized(t *testing.T) {
+	c := &V1{}
+	if err := c.SetIOWeight(100); err != ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+	if err := c.SetIOMax(IOMax{Major: 8, Minor: 0, WBps: 1 << 20}); err != ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+}
+
+func TestIOWeightToBlkIO(t *testing.T) {
+	for _, tc := range []struct{ w, want uint64 }{
+		{0, 10}, {1, 10}, {100, 19}, {10000, 1000}, {20000, 1000},
+	} {
+		if got := ioWeightToBlkIO(tc.w); got != tc.want {
+			t.Errorf("ioWeightToBlkIO(%d) = %d, want %d", tc.w, got, tc.want)
+		}
+	}
+}
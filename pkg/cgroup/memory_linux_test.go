@@ -0,0 +1,69 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestV2MemoryControls(t *testing.T) {
+	dir := t.TempDir()
+	c := &V2{path: dir, control: &Controllers{Memory: true}}
+	for _, tc := range []struct {
+		name string
+		set  func() error
+		want string
+	}{
+		{"memory.high", func() error { return c.SetMemoryHigh(1 << 20) }, "1048576"},
+		{"memory.low", func() error { return c.SetMemoryLow(1 << 10) }, "1024"},
+		{"memory.min", func() error { return c.SetMemoryMin(1) }, "1"},
+		{"memory.swap.max", func() error { return c.SetMemorySwapMax(0) }, "0"},
+		{"memory.oom.group", func() error { return c.SetMemoryOOMGroup(true) }, "1"},
+	} {
+		if err := tc.set(); err != nil {
+			t.Fatal(tc.name, err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, tc.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, b)
+		}
+	}
+}
+
+func TestV1MemorySwap(t *testing.T) {
+	dir := t.TempDir()
+	for f, c := range map[string]string{
+		"memory.limit_in_bytes":       "4096\n",
+		"memory.usage_in_bytes":       "1024\n",
+		"memory.memsw.usage_in_bytes": "3072\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(c), filePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v1 := &V1{memory: newV1Controller(dir)}
+	if err := v1.SetMemorySwapMax(1024); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "memory.memsw.limit_in_bytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "5120" {
+		t.Errorf("expected memsw limit 5120, got %s", got)
+	}
+	swap, err := v1.MemorySwapUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swap != 2048 {
+		t.Errorf("expected swap usage 2048, got %d", swap)
+	}
+	if err := v1.SetMemoryHigh(1); err != ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+}
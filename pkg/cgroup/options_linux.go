@@ -0,0 +1,90 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Options defines the cgroup file system that cgroups are created in. The zero
+// value uses the system cgroup file system
+type Options struct {
+	// Root is the mount path of the cgroup file system (default: /sys/fs/cgroup)
+	Root string
+
+	// Type is the version of the cgroup file system (default: DetectedCgroupType)
+	Type Type
+
+	// Mkdir creates a cgroup directory (default: os.Mkdir). Fake file systems
+	// use it to populate the control files
+	Mkdir func(path string, perm os.FileMode) error
+
+	// Rmdir removes a cgroup directory (default: syscall.Rmdir)
+	Rmdir func(path string) error
+}
+
+var defaultOptions = &Options{}
+
+func getOptions(opts []Options) *Options {
+	if len(opts) == 0 {
+		return defaultOptions
+	}
+	return &opts[0]
+}
+
+func (o *Options) root() string {
+	if o == nil || o.Root == "" {
+		return basePath
+	}
+	return o.Root
+}
+
+func (o *Options) cgroupType() Type {
+	if o == nil || o.Type == 0 {
+		return DetectedCgroupType
+	}
+	return o.Type
+}
+
+func (o *Options) mkdir(path string) error {
+	if o == nil || o.Mkdir == nil {
+		return os.Mkdir(path, dirPerm)
+	}
+	return o.Mkdir(path, dirPerm)
+}
+
+// mkdirAll creates the directory with its missing parents, similar to os.MkdirAll
+func (o *Options) mkdirAll(path string) error {
+	if o == nil || o.Mkdir == nil {
+		return os.MkdirAll(path, dirPerm)
+	}
+	if fi, err := os.Stat(path); err == nil {
+		if fi.IsDir() {
+			return nil
+		}
+		return &os.PathError{Op: "mkdir", Path: path, Err: os.ErrExist}
+	}
+	if parent := filepath.Dir(path); parent != path {
+		if err := o.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+	if err := o.mkdir(path); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ensureDirExists creates directories if the path not exists
+func (o *Options) ensureDirExists(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return o.mkdirAll(path)
+	}
+	return os.ErrExist
+}
+
+func (o *Options) remove(path string) error {
+	if o == nil || o.Rmdir == nil || path == "" {
+		return remove(path)
+	}
+	return o.Rmdir(path)
+}
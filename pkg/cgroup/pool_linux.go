@@ -0,0 +1,241 @@
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Pool maintains pre-created sub-cgroups to avoid creating and removing
+// cgroup directories for every run.
+//
+// Cgroups taken from the pool are returned by calling Destroy. Accumulated
+// counters are reset before reuse: cpuacct.usage and memory.max_usage_in_bytes
+// are written on v1, while on v2 the cpu usage is offset by the value at Get and
+// memory.peak is reset through an opened file descriptor (kernel >= 6.12).
+// Cgroups that cannot be reset (e.g. memory.peak on kernel < 6.12, or missing
+// on kernel < 5.19), or still contain processes, are removed instead.
+// Limits set by the previous user are kept and should be set again.
+type Pool struct {
+	parent  Cgroup
+	pattern string
+	free    chan *pooledCgroup
+
+	mu     sync.Mutex // guards returning cgroups into free after closed
+	closed bool
+}
+
+var _ Cgroup = &pooledCgroup{}
+
+// pooledCgroup is a cgroup owned by a pool
+type pooledCgroup struct {
+	Cgroup
+	pool     *Pool
+	reusable bool
+
+	peak     *os.File // v2 memory.peak
+	cpuStat  CPUStat  // v2 cpu.stat at Get
+	cpuUsage uint64   // v2 cpu usage at Get
+	oomKills uint64   // oom kill count at Get
+}
+
+// NewPool creates a pool with size pre-created sub-cgroups of the parent
+// named by the random pattern
+func NewPool(parent Cgroup, pattern string, size int) (*Pool, error) {
+	p := &Pool{
+		parent:  parent,
+		pattern: pattern,
+		free:    make(chan *pooledCgroup, size),
+	}
+	for range size {
+		cg, err := p.create()
+		if err != nil {
+			p.Destroy()
+			return nil, err
+		}
+		p.free <- cg
+	}
+	return p, nil
+}
+
+// Get takes a cgroup from the pool, or creates a new one if the pool is empty
+func (p *Pool) Get() (Cgroup, error) {
+	var cg *pooledCgroup
+	select {
+	case cg = <-p.free:
+	default:
+		var err error
+		if cg, err = p.create(); err != nil {
+			return nil, err
+		}
+	}
+	if err := cg.start(); err != nil {
+		cg.remove()
+		return nil, err
+	}
+	return cg, nil
+}
+
+// Destroy removes all cgroups in the pool, cgroups that are in use are
+// removed once returned
+func (p *Pool) Destroy() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	var err error
+	for {
+		select {
+		case cg := <-p.free:
+			if err1 := cg.remove(); err1 != nil {
+				err = err1
+			}
+		default:
+			return err
+		}
+	}
+}
+
+func (p *Pool) create() (*pooledCgroup, error) {
+	cg, err := p.parent.Random(p.pattern)
+	if err != nil {
+		return nil, err
+	}
+	pc := &pooledCgroup{Cgroup: cg, pool: p, reusable: true}
+	if v2, ok := cg.(*V2); ok && v2.control.Memory {
+		// reset of memory.peak is only visible through the same fd
+		peak := filepath.Join(v2.path, "memory.peak")
+		f, err := os.OpenFile(peak, os.O_RDWR, filePerm)
+		switch {
+		case err == nil:
+			_, err = f.WriteAt([]byte("reset"), 0)
+			pc.peak, pc.reusable = f, err == nil
+
+		case errors.Is(err, os.ErrNotExist):
+			// memory.peak does not exist on kernel < 5.19
+			pc.reusable = false
+
+		default:
+			// memory.peak cannot be reset on kernel < 6.12
+			if pc.peak, err = os.Open(peak); err != nil {
+				cg.Destroy()
+				return nil, fmt.Errorf("cgroup pool: open memory.peak: %w", err)
+			}
+			pc.reusable = false
+		}
+	}
+	return pc, nil
+}
+
+// start resets counters before the cgroup is taken
+func (c *pooledCgroup) start() error {
+	// oom kill count cannot be reset and may not exist
+	c.oomKills, _ = c.Cgroup.MemoryOOMKills()
+
+	switch cg := c.Cgroup.(type) {
+	case *V1:
+		if cg.cpuacct != nil {
+			if err := cg.SetCpuacctUsage(0); err != nil {
+				return err
+			}
+		}
+		if cg.memory != nil {
+			if err := cg.SetMemoryMaxUsageInBytes(0); err != nil {
+				return err
+			}
+		}
+
+	case *V2:
+		if c.peak != nil && c.reusable {
+			if _, err := c.peak.WriteAt([]byte("reset"), 0); err != nil {
+				return fmt.Errorf("cgroup pool: reset memory.peak: %w", err)
+			}
+		}
+		st, err := cg.CPUStat()
+		if err != nil {
+			return err
+		}
+		c.cpuStat = st
+		c.cpuUsage = uint64(st.Usage)
+	}
+	return nil
+}
+
+// Destroy returns the cgroup into the pool or removes it if it cannot be reused
+func (c *pooledCgroup) Destroy() error {
+	if !c.reusable {
+		return c.remove()
+	}
+	if procs, err := c.Processes(); err != nil || len(procs) > 0 {
+		return c.remove()
+	}
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	if c.pool.closed {
+		return c.remove()
+	}
+	select {
+	case c.pool.free <- c:
+		return nil
+	default:
+		return c.remove()
+	}
+}
+
+func (c *pooledCgroup) remove() error {
+	if c.peak != nil {
+		c.peak.Close()
+	}
+	return c.Cgroup.Destroy()
+}
+
+// CPUUsage reads cpu usage since Get
+func (c *pooledCgroup) CPUUsage() (uint64, error) {
+	u, err := c.Cgroup.CPUUsage()
+	if err != nil {
+		return 0, err
+	}
+	return u - c.cpuUsage, nil
+}
+
+// CPUStat reads cpu stat since Get
+func (c *pooledCgroup) CPUStat() (CPUStat, error) {
+	st, err := c.Cgroup.CPUStat()
+	if err != nil {
+		return st, err
+	}
+	st.Usage -= c.cpuStat.Usage
+	st.User -= c.cpuStat.User
+	st.System -= c.cpuStat.System
+	st.NrPeriods -= c.cpuStat.NrPeriods
+	st.NrThrottled -= c.cpuStat.NrThrottled
+	st.ThrottledTime -= c.cpuStat.ThrottledTime
+	return st, nil
+}
+
+// MemoryMaxUsage reads memory peak since Get
+func (c *pooledCgroup) MemoryMaxUsage() (uint64, error) {
+	if c.peak == nil {
+		return c.Cgroup.MemoryMaxUsage()
+	}
+	b := make([]byte, 32)
+	n, err := c.peak.ReadAt(b, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(b[:n])), 10, 64)
+}
+
+// MemoryOOMKills reads number of oom kills since Get
+func (c *pooledCgroup) MemoryOOMKills() (uint64, error) {
+	n, err := c.Cgroup.MemoryOOMKills()
+	if err != nil {
+		return 0, err
+	}
+	return n - c.oomKills, nil
+}
@@ -0,0 +1,119 @@
+package cgroup_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criyle/go-sandbox/pkg/cgroup"
+)
+
+// newFakePool creates a pool of size under a fake parent cgroup and returns
+// the directory of the parent
+func newFakePool(t *testing.T, opt func(*cgroup.Options), size int) (*cgroup.Pool, string) {
+	t.Helper()
+	var root string
+	parent := newFakeCgroup(t, "pool_test", func(o *cgroup.Options) {
+		root = o.Root
+		if opt != nil {
+			opt(o)
+		}
+	})
+	p, err := cgroup.NewPool(parent, "pool_*", size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		p.Destroy()
+		parent.Destroy()
+	})
+	return p, filepath.Join(root, "pool_test")
+}
+
+// subCgroups counts the sub-cgroups in dir
+func subCgroups(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPool(t *testing.T) {
+	p, _ := newFakePool(t, nil, 1)
+	cg1, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cg1.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	cg2, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cg2.Destroy()
+	if cg1 != cg2 {
+		t.Errorf("expected cgroup to be reused")
+	}
+	if u, err := cg2.CPUUsage(); err == nil && u != 0 {
+		t.Errorf("expected cpu usage to be reset, got %d", u)
+	}
+	// pool is empty, creates new one
+	for range 3 {
+		cg, err := p.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cg.Destroy()
+	}
+}
+
+func TestPoolReturnAfterDestroy(t *testing.T) {
+	p, dir := newFakePool(t, nil, 1)
+	cg, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	// the cgroup in use is removed once returned instead of kept in the pool
+	if err := cg.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if n := subCgroups(t, dir); n != 0 {
+		t.Errorf("expected no cgroup left after destroy, got %d", n)
+	}
+}
+
+func TestPoolMissingMemoryPeak(t *testing.T) {
+	// memory.peak does not exist before kernel 5.19
+	p, dir := newFakePool(t, func(o *cgroup.Options) {
+		mkdir := o.Mkdir
+		o.Mkdir = func(path string, perm os.FileMode) error {
+			if err := mkdir(path, perm); err != nil {
+				return err
+			}
+			return os.Remove(filepath.Join(path, "memory.peak"))
+		}
+	}, 1)
+	cg, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cgroup cannot be reset thus it is removed instead of reused
+	if err := cg.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if n := subCgroups(t, dir); n != 0 {
+		t.Errorf("expected cgroup to be removed, got %d", n)
+	}
+}
@@ -0,0 +1,204 @@
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PressureStat defines a single line of pressure stall information (PSI)
+type PressureStat struct {
+	Avg10  float64       // percentage of stalled time over 10s window
+	Avg60  float64       // percentage of stalled time over 60s window
+	Avg300 float64       // percentage of stalled time over 300s window
+	Total  time.Duration // total stalled time
+}
+
+// Pressure defines pressure stall information (PSI) of a resource.
+// Some is the share of time some tasks stalled and Full is the share of
+// time all tasks stalled. Full is zero for cpu at the cgroup level on
+// kernel < 5.13
+type Pressure struct {
+	Some PressureStat
+	Full PressureStat
+}
+
+// PressureSample is the pressure of cpu, memory and io at a certain time
+type PressureSample struct {
+	Time   time.Time
+	CPU    Pressure
+	Memory Pressure
+	IO     Pressure
+}
+
+// PressureStall is the time that some tasks stalled on cpu, memory and io
+type PressureStall struct {
+	CPU    time.Duration
+	Memory time.Duration
+	IO     time.Duration
+}
+
+// StallTime returns the stalled time (some) between the first and the last
+// sample, which tells a run slowed down by contention from a slow program
+func StallTime(samples []PressureSample) PressureStall {
+	if len(samples) < 2 {
+		return PressureStall{}
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	return PressureStall{
+		CPU:    last.CPU.Some.Total - first.CPU.Some.Total,
+		Memory: last.Memory.Some.Total - first.Memory.Some.Total,
+		IO:     last.IO.Some.Total - first.IO.Some.Total,
+	}
+}
+
+// CPUPressure reads cpu.pressure
+func (c *V2) CPUPressure() (Pressure, error) {
+	return c.readPressure("cpu.pressure")
+}
+
+// MemoryPressure reads memory.pressure
+func (c *V2) MemoryPressure() (Pressure, error) {
+	return c.readPressure("memory.pressure")
+}
+
+// IOPressure reads io.pressure
+func (c *V2) IOPressure() (Pressure, error) {
+	return c.readPressure("io.pressure")
+}
+
+func (c *V2) readPressure(name string) (Pressure, error) {
+	b, err := c.ReadFile(name)
+	if err != nil {
+		return Pressure{}, err
+	}
+	return parsePressure(b)
+}
+
+// CPUPressure implements Cgroup. Not exist in cgroup v1
+func (c *V1) CPUPressure() (Pressure, error) {
+	return Pressure{}, ErrNotInitialized
+}
+
+// MemoryPressure implements Cgroup. Not exist in cgroup v1
+func (c *V1) MemoryPressure() (Pressure, error) {
+	return Pressure{}, ErrNotInitialized
+}
+
+// IOPressure implements Cgroup. Not exist in cgroup v1
+func (c *V1) IOPressure() (Pressure, error) {
+	return Pressure{}, ErrNotInitialized
+}
+
+// parsePressure parses the pressure file in format of
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(b []byte) (Pressure, error) {
+	var p Pressure
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		parts := strings.Fields(s.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		var st *PressureStat
+		switch parts[0] {
+		case "some":
+			st = &p.Some
+		case "full":
+			st = &p.Full
+		default:
+			return p, fmt.Errorf("pressure: invalid line %q", s.Text())
+		}
+		for _, f := range parts[1:] {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				return p, fmt.Errorf("pressure: invalid field %q", f)
+			}
+			var err error
+			switch k {
+			case "avg10":
+				st.Avg10, err = strconv.ParseFloat(v, 64)
+			case "avg60":
+				st.Avg60, err = strconv.ParseFloat(v, 64)
+			case "avg300":
+				st.Avg300, err = strconv.ParseFloat(v, 64)
+			case "total":
+				var t uint64
+				t, err = strconv.ParseUint(v, 10, 64)
+				st.Total = time.Duration(t) * time.Microsecond
+			}
+			if err != nil {
+				return p, fmt.Errorf("pressure: %s: %w", k, err)
+			}
+		}
+	}
+	return p, s.Err()
+}
+
+// PressureSampler records the pressure of a cgroup periodically during a run
+type PressureSampler struct {
+	cg       Cgroup
+	interval time.Duration
+
+	samples  []PressureSample
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewPressureSampler creates a sampler that reads the pressure of the cgroup for
+// every interval once started
+func NewPressureSampler(cg Cgroup, interval time.Duration) *PressureSampler {
+	return &PressureSampler{
+		cg:       cg,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start records the first sample and starts sampling in a background goroutine
+func (s *PressureSampler) Start() {
+	s.sample()
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop stops sampling and returns recorded samples including a final sample.
+// Read errors are ignored so missing pressure files result in zero values.
+// Calling Stop again returns the same samples
+func (s *PressureSampler) Stop() []PressureSample {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.sample()
+	})
+	return s.samples
+}
+
+func (s *PressureSampler) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.sample()
+		}
+	}
+}
+
+func (s *PressureSampler) sample() {
+	ps := PressureSample{Time: time.Now()}
+	ps.CPU, _ = s.cg.CPUPressure()
+	ps.Memory, _ = s.cg.MemoryPressure()
+	ps.IO, _ = s.cg.IOPressure()
+	s.samples = append(s.samples, ps)
+}
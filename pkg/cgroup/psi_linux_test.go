@@ -0,0 +1,77 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testPressure = `some avg10=1.50 avg60=0.25 avg300=0.00 total=123456
+full avg10=0.75 avg60=0.00 avg300=0.00 total=654
+`
+
+func TestParsePressure(t *testing.T) {
+	p, err := parsePressure([]byte(testPressure))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pressure{
+		Some: PressureStat{Avg10: 1.5, Avg60: 0.25, Total: 123456 * time.Microsecond},
+		Full: PressureStat{Avg10: 0.75, Total: 654 * time.Microsecond},
+	}
+	if p != want {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+	if _, err := parsePressure([]byte("invalid avg10=0.00\n")); err == nil {
+		t.Errorf("expected error for invalid line")
+	}
+}
+
+func TestStallTime(t *testing.T) {
+	sample := func(cpu, memory, io time.Duration) PressureSample {
+		return PressureSample{
+			CPU:    Pressure{Some: PressureStat{Total: cpu}},
+			Memory: Pressure{Some: PressureStat{Total: memory}},
+			IO:     Pressure{Some: PressureStat{Total: io}},
+		}
+	}
+	samples := []PressureSample{
+		sample(time.Second, 2*time.Second, 3*time.Second),
+		sample(time.Second+time.Millisecond, 2*time.Second, 3*time.Second),
+		sample(time.Second+5*time.Millisecond, 2*time.Second+time.Millisecond, 3*time.Second),
+	}
+	want := PressureStall{CPU: 5 * time.Millisecond, Memory: time.Millisecond}
+	if st := StallTime(samples); st != want {
+		t.Errorf("expected %+v, got %+v", want, st)
+	}
+	if st := StallTime(samples[:1]); st != (PressureStall{}) {
+		t.Errorf("expected no stall for single sample, got %+v", st)
+	}
+}
+
+func TestPressureSampler(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"cpu.pressure", "memory.pressure", "io.pressure"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(testPressure), filePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewPressureSampler(&V2{path: dir, control: &Controllers{}}, time.Millisecond)
+	s.Start()
+	time.Sleep(10 * time.Millisecond)
+	samples := s.Stop()
+	if len(samples) < 2 {
+		t.Fatalf("expected at least 2 samples, got %d", len(samples))
+	}
+	if samples[0].Memory.Some.Total != 123456*time.Microsecond {
+		t.Errorf("unexpected sample: %+v", samples[0])
+	}
+	if st := StallTime(samples); st != (PressureStall{}) {
+		t.Errorf("expected no stall for unchanged pressure, got %+v", st)
+	}
+	// stop twice should not panic
+	if again := s.Stop(); len(again) != len(samples) {
+		t.Errorf("expected %d samples on second stop, got %d", len(samples), len(again))
+	}
+}
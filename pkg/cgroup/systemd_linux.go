@@ -0,0 +1,212 @@
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdDest       = "org.freedesktop.systemd1"
+	systemdPath       = "/org/freedesktop/systemd1"
+	systemdManager    = "org.freedesktop.systemd1.Manager"
+	systemdScope      = "org.freedesktop.systemd1.Scope"
+	systemdJobRemoved = "JobRemoved"
+
+	scopeSuffix = ".scope"
+
+	// defaultJobTimeout is the time to wait for the scope job to finish
+	defaultJobTimeout = 30 * time.Second
+
+	// initCgroup is the sub-cgroup the current process is moved into so that
+	// the delegated scope has no internal process
+	initCgroup = "init"
+)
+
+var errSystemdV1 = errors.New("systemd: delegation requires cgroup v2")
+
+// SystemdOptions defines the transient scope requested from systemd
+type SystemdOptions struct {
+	// Options defines the cgroup file system the scope is created in
+	Options
+
+	// Address is the D-Bus address to reach systemd (default: system bus for
+	// root, otherwise the session bus of the user manager)
+	Address string
+
+	// Slice is the slice the scope is placed in (default: the systemd default)
+	Slice string
+
+	// Description is the description of the scope unit
+	Description string
+
+	// Timeout is the time to wait for systemd to start the scope (default: 30s)
+	Timeout time.Duration
+}
+
+// NewSystemd asks systemd over D-Bus for a transient scope with Delegate=yes
+// that contains the current process, which is then moved into the "init"
+// sub-cgroup of the scope so that sub-cgroups can be created inside it.
+//
+// The returned cgroup is owned by systemd so Destroy does not remove it. The
+// scope is collected by systemd once all processes inside it exit.
+func NewSystemd(unit string, ct *Controllers, opts ...SystemdOptions) (Cgroup, error) {
+	var opt SystemdOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.cgroupType() == TypeV1 {
+		return nil, errSystemdV1
+	}
+	if !strings.HasSuffix(unit, scopeSuffix) {
+		unit += scopeSuffix
+	}
+
+	conn, err := connectSystemd(opt.Address)
+	if err != nil {
+		return nil, fmt.Errorf("systemd: connect: %w", err)
+	}
+	defer conn.Close()
+
+	path, err := startTransientScope(conn, unit, ct, &opt)
+	if err != nil {
+		return nil, fmt.Errorf("systemd: start %s: %w", unit, err)
+	}
+
+	scope, err := OpenExisting(strings.TrimPrefix(path, "/"), ct, opt.Options)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := scope.Nest(initCgroup); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+func connectSystemd(address string) (*dbus.Conn, error) {
+	switch {
+	case address != "":
+		return dbus.Connect(address)
+	case os.Geteuid() == 0:
+		return dbus.ConnectSystemBus()
+	default:
+		return dbus.ConnectSessionBus()
+	}
+}
+
+// startTransientScope starts the scope unit, waits for the job to finish and
+// returns the control group path of the scope
+func startTransientScope(conn *dbus.Conn, unit string, ct *Controllers, opt *SystemdOptions) (string, error) {
+	// subscribe before starting so that the job removal is not missed
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface(systemdManager),
+		dbus.WithMatchMember(systemdJobRemoved),
+	); err != nil {
+		return "", err
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	manager := conn.Object(systemdDest, systemdPath)
+	if err := manager.Call(systemdManager+".Subscribe", 0).Err; err != nil {
+		return "", err
+	}
+
+	var job dbus.ObjectPath
+	if err := manager.Call(systemdManager+".StartTransientUnit", 0,
+		unit, "fail", scopeProperties(ct, opt), []struct {
+			Name       string
+			Properties []systemdProperty
+		}{},
+	).Store(&job); err != nil {
+		return "", err
+	}
+	timeout := opt.Timeout
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+	if err := waitJob(signals, job, timeout); err != nil {
+		return "", err
+	}
+
+	var unitPath dbus.ObjectPath
+	if err := manager.Call(systemdManager+".GetUnit", 0, unit).Store(&unitPath); err != nil {
+		return "", err
+	}
+	v, err := conn.Object(systemdDest, unitPath).GetProperty(systemdScope + ".ControlGroup")
+	if err != nil {
+		return "", err
+	}
+	path, ok := v.Value().(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("invalid control group %v", v)
+	}
+	return path, nil
+}
+
+// waitJob waits for JobRemoved signal of the job and checks its result. It
+// returns error if the signal does not arrive within timeout
+func waitJob(signals <-chan *dbus.Signal, job dbus.ObjectPath, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		var s *dbus.Signal
+		select {
+		case <-timer.C:
+			return fmt.Errorf("job %s: timeout after %v", job, timeout)
+		case sig, ok := <-signals:
+			if !ok {
+				return errors.New("connection closed")
+			}
+			s = sig
+		}
+		if s.Name != systemdManager+"."+systemdJobRemoved || len(s.Body) < 4 {
+			continue
+		}
+		if p, _ := s.Body[1].(dbus.ObjectPath); p != job {
+			continue
+		}
+		if r, _ := s.Body[3].(string); r != "done" {
+			return fmt.Errorf("job %s: %s", job, r)
+		}
+		return nil
+	}
+}
+
+type systemdProperty struct {
+	Name  string
+	Value dbus.Variant
+}
+
+func scopeProperties(ct *Controllers, opt *SystemdOptions) []systemdProperty {
+	p := []systemdProperty{
+		{"Delegate", dbus.MakeVariant(true)},
+		{"PIDs", dbus.MakeVariant([]uint32{uint32(os.Getpid())})},
+		{"CollectMode", dbus.MakeVariant("inactive-or-failed")},
+	}
+	if opt.Slice != "" {
+		p = append(p, systemdProperty{"Slice", dbus.MakeVariant(opt.Slice)})
+	}
+	if opt.Description != "" {
+		p = append(p, systemdProperty{"Description", dbus.MakeVariant(opt.Description)})
+	}
+	for _, v := range []struct {
+		e bool
+		n string
+	}{
+		{ct.CPU, "CPUAccounting"},
+		{ct.Memory, "MemoryAccounting"},
+		{ct.Pids, "TasksAccounting"},
+		{ct.IO, "IOAccounting"},
+	} {
+		if v.e {
+			p = append(p, systemdProperty{v.n, dbus.MakeVariant(true)})
+		}
+	}
+	return p
+}
@@ -0,0 +1,28 @@
+package cgroup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestWaitJob(t *testing.T) {
+	const job = dbus.ObjectPath("/org/freedesktop/systemd1/job/1")
+	signals := make(chan *dbus.Signal, 2)
+	signals <- &dbus.Signal{Name: systemdManager + "." + systemdJobRemoved, Body: []any{uint32(2), dbus.ObjectPath("/other"), "a.scope", "done"}}
+	signals <- &dbus.Signal{Name: systemdManager + "." + systemdJobRemoved, Body: []any{uint32(1), job, "a.scope", "done"}}
+	if err := waitJob(signals, job, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// JobRemoved of the job never arrives
+	if err := waitJob(signals, job, 10*time.Millisecond); err == nil {
+		t.Fatal("expected timeout")
+	}
+
+	close(signals)
+	if err := waitJob(signals, job, time.Second); err == nil {
+		t.Fatal("expected connection closed")
+	}
+}
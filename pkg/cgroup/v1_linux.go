@@ -19,10 +19,12 @@ type V1 struct {
 	cpuacct *v1controller
 	memory  *v1controller
 	pids    *v1controller
+	blkio   *v1controller
 
 	all []*v1controller
 
 	existing bool
+	opt      *Options
 }
 
 func (c *V1) Open() (*os.File, error) {
@@ -40,6 +42,7 @@ func (c *V1) String() string {
 		{c.cpuacct, CPUAcct},
 		{c.memory, Memory},
 		{c.pids, Pids},
+		{c.blkio, BlkIO},
 	} {
 		if v.now == nil {
 			continue
@@ -71,11 +74,12 @@ func (c *V1) Processes() ([]int, error) {
 func (c *V1) New(name string) (cg Cgroup, err error) {
 	v1 := &V1{
 		prefix: filepath.Join(c.prefix, name),
+		opt:    c.opt,
 	}
 	defer func() {
 		if err != nil {
 			for _, v := range v1.all {
-				remove(v.path)
+				c.opt.remove(v.path)
 			}
 		}
 	}()
@@ -88,13 +92,14 @@ func (c *V1) New(name string) (cg Cgroup, err error) {
 		{c.cpuacct, &v1.cpuacct},
 		{c.memory, &v1.memory},
 		{c.pids, &v1.pids},
+		{c.blkio, &v1.blkio},
 	} {
 		if v.now == nil {
 			continue
 		}
 		p := filepath.Join(v.now.path, name)
 		*v.new = &v1controller{path: p}
-		err = EnsureDirExists(p)
+		err = c.opt.ensureDirExists(p)
 		if os.IsExist(err) {
 			err = nil
 			if len(v1.all) == 0 {
@@ -144,7 +149,7 @@ func (c *V1) Destroy() error {
 		if c.existing {
 			continue
 		}
-		if err := remove(s.path); err != nil {
+		if err := c.opt.remove(s.path); err != nil {
 			err1 = err
 		}
 	}
@@ -194,6 +199,71 @@ func (c *V1) SetMemoryLimit(i uint64) error {
 	return c.memory.WriteUint("memory.limit_in_bytes", i)
 }
 
+// SetMemoryHigh implements Cgroup. Not exist in cgroup v1
+func (c *V1) SetMemoryHigh(uint64) error {
+	return ErrNotInitialized
+}
+
+// SetMemoryLow write memory.soft_limit_in_bytes
+func (c *V1) SetMemoryLow(i uint64) error {
+	return c.memory.WriteUint("memory.soft_limit_in_bytes", i)
+}
+
+// SetMemoryMin implements Cgroup. Not exist in cgroup v1
+func (c *V1) SetMemoryMin(uint64) error {
+	return ErrNotInitialized
+}
+
+// SetMemorySwapMax write memory.memsw.limit_in_bytes as the sum of
+// memory.limit_in_bytes and the swap limit
+func (c *V1) SetMemorySwapMax(i uint64) error {
+	l, err := c.memory.ReadUint("memory.limit_in_bytes")
+	if err != nil {
+		return err
+	}
+	// avoid overflow when memory is unlimited
+	if l+i < l {
+		return c.SetMemoryMemswLimitInBytes(l)
+	}
+	return c.SetMemoryMemswLimitInBytes(l + i)
+}
+
+// SetMemoryOOMGroup implements Cgroup. Not exist in cgroup v1
+func (c *V1) SetMemoryOOMGroup(bool) error {
+	return ErrNotInitialized
+}
+
+// MemorySwapUsage read the difference between memory.memsw.usage_in_bytes and
+// memory.usage_in_bytes
+func (c *V1) MemorySwapUsage() (uint64, error) {
+	memsw, err := c.memory.ReadUint("memory.memsw.usage_in_bytes")
+	if err != nil {
+		return 0, err
+	}
+	mem, err := c.memory.ReadUint("memory.usage_in_bytes")
+	if err != nil {
+		return 0, err
+	}
+	if memsw < mem {
+		return 0, nil
+	}
+	return memsw - mem, nil
+}
+
+// MemorySwapMaxUsage implements Cgroup. Not exist in cgroup v1, use
+// MemoryMemswMaxUsageInBytes for the max usage of memory and swap
+func (c *V1) MemorySwapMaxUsage() (uint64, error) {
+	return 0, ErrNotInitialized
+}
+
+// MemoryOOMKills reads oom_kill from memory.oom_control
+func (c *V1) MemoryOOMKills() (uint64, error) {
+	if c.memory == nil {
+		return 0, ErrNotInitialized
+	}
+	return readFlatKey(c.memory.ReadFile, "memory.oom_control", "oom_kill")
+}
+
 // SetProcLimit write pids.max
 func (c *V1) SetProcLimit(i uint64) error {
 	return c.pids.WriteUint("pids.max", i)
@@ -17,6 +17,7 @@ type V2 struct {
 	subtreeOnce sync.Once
 	subtreeErr  error
 	existing    bool
+	opt         *Options
 }
 
 var _ Cgroup = &V2{}
@@ -48,8 +49,9 @@ func (c *V2) New(name string) (Cgroup, error) {
 	v2 := &V2{
 		path:    filepath.Join(c.path, name),
 		control: c.control,
+		opt:     c.opt,
 	}
-	if err := os.Mkdir(v2.path, dirPerm); err != nil {
+	if err := c.opt.mkdir(v2.path); err != nil {
 		if !os.IsExist(err) {
 			return nil, err
 		}
@@ -63,8 +65,9 @@ func (c *V2) Nest(name string) (Cgroup, error) {
 	v2 := &V2{
 		path:    filepath.Join(c.path, name),
 		control: c.control,
+		opt:     c.opt,
 	}
-	if err := os.Mkdir(v2.path, dirPerm); err != nil {
+	if err := c.opt.mkdir(v2.path); err != nil {
 		if !os.IsExist(err) {
 			return nil, err
 		}
@@ -113,7 +116,7 @@ func (c *V2) Random(pattern string) (Cgroup, error) {
 // Destroy destroys the cgroup
 func (c *V2) Destroy() error {
 	if !c.existing {
-		return remove(c.path)
+		return c.opt.remove(c.path)
 	}
 	return nil
 }
@@ -192,6 +195,74 @@ func (c *V2) SetMemoryLimit(l uint64) error {
 	return c.WriteUint("memory.max", l)
 }
 
+// SetMemoryHigh memory.high
+func (c *V2) SetMemoryHigh(l uint64) error {
+	if !c.control.Memory {
+		return ErrNotInitialized
+	}
+	return c.WriteUint("memory.high", l)
+}
+
+// SetMemoryLow memory.low
+func (c *V2) SetMemoryLow(l uint64) error {
+	if !c.control.Memory {
+		return ErrNotInitialized
+	}
+	return c.WriteUint("memory.low", l)
+}
+
+// SetMemoryMin memory.min
+func (c *V2) SetMemoryMin(l uint64) error {
+	if !c.control.Memory {
+		return ErrNotInitialized
+	}
+	return c.WriteUint("memory.min", l)
+}
+
+// SetMemorySwapMax memory.swap.max
+func (c *V2) SetMemorySwapMax(l uint64) error {
+	if !c.control.Memory {
+		return ErrNotInitialized
+	}
+	return c.WriteUint("memory.swap.max", l)
+}
+
+// SetMemoryOOMGroup memory.oom.group
+func (c *V2) SetMemoryOOMGroup(group bool) error {
+	if !c.control.Memory {
+		return ErrNotInitialized
+	}
+	var v uint64
+	if group {
+		v = 1
+	}
+	return c.WriteUint("memory.oom.group", v)
+}
+
+// MemorySwapUsage reads memory.swap.current
+func (c *V2) MemorySwapUsage() (uint64, error) {
+	if !c.control.Memory {
+		return 0, ErrNotInitialized
+	}
+	return c.ReadUint("memory.swap.current")
+}
+
+// MemorySwapMaxUsage reads memory.swap.peak
+func (c *V2) MemorySwapMaxUsage() (uint64, error) {
+	if !c.control.Memory {
+		return 0, ErrNotInitialized
+	}
+	return c.ReadUint("memory.swap.peak")
+}
+
+// MemoryOOMKills reads oom_kill from memory.events
+func (c *V2) MemoryOOMKills() (uint64, error) {
+	if !c.control.Memory {
+		return 0, ErrNotInitialized
+	}
+	return readFlatKey(c.ReadFile, "memory.events", "oom_kill")
+}
+
 // SetProcLimit pids.max
 func (c *V2) SetProcLimit(l uint64) error {
 	if !c.control.Pids {
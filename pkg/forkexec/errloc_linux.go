@@ -2,6 +2,7 @@ package forkexec
 
 import (
 	"fmt"
+	"log/slog"
 	"syscall"
 )
 
@@ -100,3 +101,16 @@ func (e ChildError) Error() string {
 	}
 	return fmt.Sprintf("%s: %s", e.Location.String(), e.Err.Error())
 }
+
+// LogValue implements slog.LogValuer to log the error with structured fields
+func (e ChildError) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("location", e.Location.String()),
+		slog.String("err", e.Err.Error()),
+		slog.Int("errno", int(e.Err)),
+	}
+	if e.Index > 0 {
+		attrs = append(attrs, slog.Int("index", e.Index))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -30,7 +30,7 @@ func forkAndExecInChild(r *Runner, argv0 *byte, argv, env []*byte, workdir, host
 	fd, nextfd := prepareFds(r.Files)
 
 	flag := r.CloneFlags & UnshareFlags
-	if r.SyncFunc == nil && !(r.StopBeforeSeccomp || (r.Seccomp != nil && r.Ptrace)) && flag&syscall.CLONE_NEWUSER != syscall.CLONE_NEWUSER {
+	if r.SyncFunc == nil && !(r.StopBeforeSeccomp || (r.Ptrace && (r.Seccomp != nil || r.PtraceSeize))) && flag&syscall.CLONE_NEWUSER != syscall.CLONE_NEWUSER {
 		flag |= syscall.CLONE_VM | syscall.CLONE_VFORK
 	}
 
@@ -368,9 +368,10 @@ func forkAndExecInChild(r *Runner, argv0 *byte, argv, env []*byte, workdir, host
 	}
 
 	// Enable Ptrace & sync with parent (since ptrace_me is a blocking operation)
-	if r.Ptrace && r.Seccomp != nil {
+	// For PtraceSeize, the parent attaches during the sync
+	if r.Ptrace && (r.Seccomp != nil || r.PtraceSeize) {
 		{
-			if r.SyncFunc != nil {
+			if r.SyncFunc != nil || r.PtraceSeize {
 				r1, _, err1 = syscall.RawSyscall(syscall.SYS_WRITE, uintptr(pipe), uintptr(unsafe.Pointer(&err2)), uintptr(unsafe.Sizeof(err2)))
 				if r1 == 0 || err1 != 0 {
 					childExitError(pipe, LocSyncWrite, err1)
@@ -401,16 +402,18 @@ func forkAndExecInChild(r *Runner, argv0 *byte, argv, env []*byte, workdir, host
 				}
 			}
 		}
-		_, _, err1 = syscall.RawSyscall(syscall.SYS_PTRACE, uintptr(syscall.PTRACE_TRACEME), 0, 0)
-		if err1 != 0 {
-			childExitError(pipe, LocPtraceMe, err1)
+		if !r.PtraceSeize {
+			_, _, err1 = syscall.RawSyscall(syscall.SYS_PTRACE, uintptr(syscall.PTRACE_TRACEME), 0, 0)
+			if err1 != 0 {
+				childExitError(pipe, LocPtraceMe, err1)
+			}
 		}
 	}
 
 	// if both seccomp and ptrace is defined, then seccomp filter should have
 	// traced execve, thus child need parent attached to it first
 	// actually, this is not effective if pid namespace is unshared
-	if r.StopBeforeSeccomp || (r.Seccomp != nil && r.Ptrace) {
+	if r.StopBeforeSeccomp || (r.Seccomp != nil && r.Ptrace && !r.PtraceSeize) {
 		// Stop to wait for ptrace tracer
 		_, _, err1 = syscall.RawSyscall(syscall.SYS_KILL, pid, uintptr(syscall.SIGSTOP), 0)
 		if err1 != 0 {
@@ -433,7 +436,7 @@ func forkAndExecInChild(r *Runner, argv0 *byte, argv, env []*byte, workdir, host
 	}
 
 	// Before exec, sync with parent through pipe (configured as close_on_exec)
-	if !r.Ptrace || r.Seccomp == nil {
+	if !r.Ptrace || (r.Seccomp == nil && !r.PtraceSeize) {
 		{
 			if r.SyncFunc != nil {
 				r1, _, err1 = syscall.RawSyscall(syscall.SYS_WRITE, uintptr(pipe), uintptr(unsafe.Pointer(&err2)), uintptr(unsafe.Sizeof(err2)))
@@ -477,7 +480,7 @@ func forkAndExecInChild(r *Runner, argv0 *byte, argv, env []*byte, workdir, host
 	}
 
 	// Enable ptrace if no seccomp is needed
-	if r.Ptrace && r.Seccomp == nil {
+	if r.Ptrace && r.Seccomp == nil && !r.PtraceSeize {
 		_, _, err1 = syscall.RawSyscall(syscall.SYS_PTRACE, uintptr(syscall.PTRACE_TRACEME), 0, 0)
 		if err1 != 0 {
 			childExitError(pipe, LocPtraceMe, err1)
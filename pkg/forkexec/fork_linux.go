@@ -64,6 +64,7 @@ func syncWithChild(r *Runner, p [2]int, pid int, err1 syscall.Errno) (int, error
 		err2        syscall.Errno
 		err         error
 		unshareUser = r.CloneFlags&unix.CLONE_NEWUSER == unix.CLONE_NEWUSER
+		seize       = r.Ptrace && r.PtraceSeize
 		childErr    ChildError
 		n           int
 	)
@@ -88,23 +89,32 @@ func syncWithChild(r *Runner, p [2]int, pid int, err1 syscall.Errno) (int, error
 	}
 
 	// if syncfunc return error, then fail child immediately
-	// only sync if there is a syncFunc
-	if r.SyncFunc != nil {
+	// only sync if there is a syncFunc or the child waits to be seized
+	if r.SyncFunc != nil || seize {
 		n, err = readChildErr(p[0], &childErr)
 		// child returned error code
 		if (n != int(unsafe.Sizeof(err2)) && n != int(unsafe.Sizeof(childErr))) || childErr.Err != 0 || err != nil {
 			childErr.Err = handlePipeError(n, childErr.Err)
 			goto fail
 		}
-		if err = r.SyncFunc(int(pid)); err != nil {
-			goto fail
+		if r.SyncFunc != nil {
+			if err = r.SyncFunc(int(pid)); err != nil {
+				goto fail
+			}
+		}
+		// attach before the child loads seccomp filter so that execve is traced
+		if seize {
+			if err = ptraceSeize(pid, r.PtraceOptions|unix.PTRACE_O_EXITKILL); err != nil {
+				goto fail
+			}
 		}
 		// otherwise, ack child (err1 == 0)
 		syscall.RawSyscall(syscall.SYS_WRITE, uintptr(p[0]), uintptr(unsafe.Pointer(&err1)), uintptr(unsafe.Sizeof(err1)))
 	}
 
-	// if stopped before execve by signal SIGSTOP or PTRACE_ME, then do not wait until execve
-	if r.StopBeforeSeccomp || (r.Seccomp != nil && r.Ptrace) {
+	// if stopped before execve by signal SIGSTOP, PTRACE_ME or PTRACE_SEIZE,
+	// then do not wait until execve
+	if r.StopBeforeSeccomp || (r.Seccomp != nil && r.Ptrace) || seize {
 		// let's wait it in another goroutine to avoid SIGPIPE
 		go func() {
 			readChildErr(p[0], &childErr)
@@ -153,6 +163,18 @@ func readlen(fd int, p *byte, np int) (n int, err error) {
 	return
 }
 
+// ptraceSeize attaches the process as tracee of the calling thread with options.
+// No PTRACE_INTERRUPT is sent for an initial stop: the child is blocked on the
+// sync pipe while the options are set atomically by the seize, so its first
+// stop is the exec or seccomp event after the ack and nothing runs untraced
+func ptraceSeize(pid int, options int) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, unix.PTRACE_SEIZE, uintptr(pid), 0, uintptr(options), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
 // check pipe error
 func handlePipeError(r1 int, errno syscall.Errno) syscall.Errno {
 	if uintptr(r1) >= unsafe.Sizeof(errno) {
@@ -78,6 +78,14 @@ type Runner struct {
 	// runtime.LockOSThread is required for tracer to call ptrace syscalls
 	Ptrace bool
 
+	// ptrace_seize makes the parent attach the child by ptrace(PTRACE_SEIZE)
+	// with PtraceOptions (PTRACE_O_EXITKILL is always set) instead of
+	// PTRACE_TRACEME and SIGSTOP. The child waits on the sync socket until
+	// it is attached, so that it does not need to stop itself. Only effective
+	// if Ptrace is set
+	PtraceSeize   bool
+	PtraceOptions int
+
 	// no_new_privs calls prctl(PR_SET_NO_NEW_PRIVS) to 0 to disable calls to
 	// setuid processes. It is automatically enabled when seccomp filter is provided
 	NoNewPrivs bool
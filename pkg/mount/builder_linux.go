@@ -21,11 +21,15 @@ func NewDefaultBuilder() *Builder {
 		WithBind("/bin", "bin", true)
 }
 
-// Build creates sequence of syscalls for fork_exec
+// Build validates the mount plan and creates sequence of syscalls for fork_exec
+// ordered by target depth
 func (b *Builder) Build() ([]SyscallParams, error) {
-	var err error
-	ret := make([]SyscallParams, 0, len(b.Mounts))
-	for _, m := range b.Mounts {
+	mounts, err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]SyscallParams, 0, len(mounts))
+	for _, m := range mounts {
 		var mknod bool
 		if mknod, err = isBindMountFileOrNotExists(m); err != nil {
 			return nil, err
@@ -92,6 +96,14 @@ func (b *Builder) WithBind(source, target string, readonly bool) *Builder {
 	return b
 }
 
+// WithIDMappedBind adds an ID-mapped bind mount to builder, ids are mapped by
+// the user namespace of the container (container only)
+func (b *Builder) WithIDMappedBind(source, target string, readonly bool) *Builder {
+	b.WithBind(source, target, readonly)
+	b.Mounts[len(b.Mounts)-1].IDMapped = true
+	return b
+}
+
 // WithTmpfs adds a tmpfs mount to builder
 func (b *Builder) WithTmpfs(target, data string) *Builder {
 	b.Mounts = append(b.Mounts, Mount{
@@ -1,13 +1,20 @@
 package mount
 
 import (
+	"errors"
 	"syscall"
 )
 
+var errIDMappedSyscall = errors.New("mount: id-mapped mount cannot be converted to mount syscall")
+
 // Mount defines syscall for mount points
 type Mount struct {
 	Source, Target, FsType, Data string
 	Flags                        uintptr
+
+	// IDMapped creates the bind mount as ID-mapped mount with the user namespace
+	// of the container, so that files are accessed with the container ids
+	IDMapped bool
 }
 
 // SyscallParams defines the raw syscall arguments to mount
@@ -21,6 +28,9 @@ type SyscallParams struct {
 // ToSyscall convert Mount to SyscallPrams
 func (m *Mount) ToSyscall() (*SyscallParams, error) {
 	var data *byte
+	if m.IDMapped {
+		return nil, errIDMappedSyscall
+	}
 	source, err := syscall.BytePtrFromString(m.Source)
 	if err != nil {
 		return nil, err
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+
+	"golang.org/x/sys/unix"
 )
 
 // Mount calls mount syscall
@@ -32,6 +34,45 @@ func (m *Mount) Mount() error {
 	return nil
 }
 
+// OpenIDMapped creates a detached ID-mapped bind mount of the source that maps ids
+// by the user namespace referred by usernsFd. The caller needs CAP_SYS_ADMIN in
+// the user namespace that owns the source file system (Linux >= 5.12)
+func (m *Mount) OpenIDMapped(usernsFd int) (int, error) {
+	fd, err := unix.OpenTree(unix.AT_FDCWD, m.Source, unix.OPEN_TREE_CLONE|unix.OPEN_TREE_CLOEXEC|unix.AT_RECURSIVE)
+	if err != nil {
+		return -1, fmt.Errorf("open_tree: %w", err)
+	}
+	attr := unix.MountAttr{
+		Attr_set:  unix.MOUNT_ATTR_IDMAP | unix.MOUNT_ATTR_NOSUID,
+		Userns_fd: uint64(usernsFd),
+	}
+	if m.IsReadOnly() {
+		attr.Attr_set |= unix.MOUNT_ATTR_RDONLY
+	}
+	if m.Flags&syscall.MS_NODEV == syscall.MS_NODEV {
+		attr.Attr_set |= unix.MOUNT_ATTR_NODEV
+	}
+	if m.Flags&syscall.MS_NOEXEC == syscall.MS_NOEXEC {
+		attr.Attr_set |= unix.MOUNT_ATTR_NOEXEC
+	}
+	if err := unix.MountSetattr(fd, "", unix.AT_EMPTY_PATH|unix.AT_RECURSIVE, &attr); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("mount_setattr: %w", err)
+	}
+	return fd, nil
+}
+
+// MoveMount attaches the detached mount created by OpenIDMapped to the target
+func (m *Mount) MoveMount(fd int) error {
+	if err := ensureMountTargetExists(m.Source, m.Target); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	if err := unix.MoveMount(fd, "", unix.AT_FDCWD, m.Target, unix.MOVE_MOUNT_F_EMPTY_PATH); err != nil {
+		return fmt.Errorf("move_mount: %w", err)
+	}
+	return nil
+}
+
 // IsBindMount returns if it is a bind mount
 func (m Mount) IsBindMount() bool {
 	return m.Flags&syscall.MS_BIND == syscall.MS_BIND
@@ -78,6 +119,9 @@ func (m Mount) String() string {
 		flag = "ro"
 	}
 	switch {
+	case m.Flags&syscall.MS_BIND == syscall.MS_BIND && m.IDMapped:
+		return fmt.Sprintf("bind[%s:%s:%s,idmap]", m.Source, m.Target, flag)
+
 	case m.Flags&syscall.MS_BIND == syscall.MS_BIND:
 		return fmt.Sprintf("bind[%s:%s:%s]", m.Source, m.Target, flag)
 
@@ -0,0 +1,137 @@
+package mount
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrorReason defines the reason why a mount plan was rejected
+type ErrorReason int
+
+// Reason constants
+const (
+	ReasonOutsideRoot ErrorReason = iota + 1
+	ReasonDuplicate
+	ReasonShadowed
+	ReasonReadOnlyParent
+)
+
+var reasonToString = []string{
+	"unknown",
+	"target outside root",
+	"duplicated mount",
+	"shadowed mount",
+	"parent is read-only",
+}
+
+func (r ErrorReason) String() string {
+	if r >= ReasonOutsideRoot && r <= ReasonReadOnlyParent {
+		return reasonToString[r]
+	}
+	return "unknown"
+}
+
+// PlanError defines the mount that was rejected and the mount it conflicts with
+type PlanError struct {
+	Reason ErrorReason
+	Mount  Mount
+	// Conflict is the earlier mount that conflicts with Mount, if any
+	Conflict *Mount
+}
+
+func (e *PlanError) Error() string {
+	if e.Conflict != nil {
+		return fmt.Sprintf("mount: %s: %v conflicts with %v", e.Reason, e.Mount, *e.Conflict)
+	}
+	return fmt.Sprintf("mount: %s: %v", e.Reason, e.Mount)
+}
+
+// Validate checks the mount plan and returns the mounts ordered by target depth
+// so that parent mount points are always mounted before their children.
+//
+// An empty target mounts over the root itself. It rejects targets outside the
+// root (absolute or escaping via ".."), identical mounts added twice, different
+// mounts on the same target and mount points that need to be created under a
+// read-only mount.
+func (b *Builder) Validate() ([]Mount, error) {
+	type entry struct {
+		m      Mount
+		target string
+		depth  int
+	}
+	entries := make([]entry, 0, len(b.Mounts))
+	for _, m := range b.Mounts {
+		t, ok := cleanTarget(m.Target)
+		if !ok {
+			return nil, &PlanError{Reason: ReasonOutsideRoot, Mount: m}
+		}
+		depth := 0
+		if t != "." {
+			depth = strings.Count(t, "/") + 1
+		}
+		entries = append(entries, entry{m: m, target: t, depth: depth})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].depth < entries[j].depth
+	})
+
+	ret := make([]Mount, 0, len(entries))
+	byTarget := make(map[string]int, len(entries))
+	for i, e := range entries {
+		if j, ok := byTarget[e.target]; ok {
+			r := ReasonShadowed
+			if prev := entries[j].m; prev.Source == e.m.Source && prev.FsType == e.m.FsType &&
+				prev.Flags == e.m.Flags && prev.Data == e.m.Data && prev.IDMapped == e.m.IDMapped {
+				r = ReasonDuplicate
+			}
+			return nil, &PlanError{Reason: r, Mount: e.m, Conflict: &entries[j].m}
+		}
+		byTarget[e.target] = i
+
+		// find the closest mount point above the target, including the root
+		for p := e.target; p != "."; {
+			p = filepath.Dir(p)
+			j, ok := byTarget[p]
+			if !ok {
+				continue
+			}
+			parent := entries[j].m
+			if parent.IsReadOnly() && !existsUnder(parent, p, e.target) {
+				return nil, &PlanError{Reason: ReasonReadOnlyParent, Mount: e.m, Conflict: &parent}
+			}
+			break
+		}
+		ret = append(ret, e.m)
+	}
+	return ret, nil
+}
+
+// cleanTarget returns the cleaned target relative to the root and whether it
+// stays inside the root
+func cleanTarget(target string) (string, bool) {
+	if filepath.IsAbs(target) {
+		return "", false
+	}
+	t := filepath.Clean(target)
+	if t == ".." || strings.HasPrefix(t, "../") {
+		return "", false
+	}
+	return t, true
+}
+
+// existsUnder checks whether the target already exists inside the source of
+// the read-only bind mount on parentTarget, so no mkdir is needed
+func existsUnder(parent Mount, parentTarget, target string) bool {
+	if !parent.IsBindMount() {
+		return false
+	}
+	rel, err := filepath.Rel(parentTarget, target)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(parent.Source, rel))
+	return err == nil
+}
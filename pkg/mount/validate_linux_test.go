@@ -0,0 +1,94 @@
+package mount
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuilder_ValidateOrder(t *testing.T) {
+	b := NewBuilder().
+		WithBind("/src", "w/a/b", false).
+		WithTmpfs("tmp", "").
+		WithTmpfs("w", "").
+		WithBind("/src", "w/a", false)
+	ms, err := b.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tmp", "w", "w/a", "w/a/b"}
+	if len(ms) != len(want) {
+		t.Fatalf("expected %d mounts, got %d", len(want), len(ms))
+	}
+	for i, m := range ms {
+		if m.Target != want[i] {
+			t.Errorf("mount %d: expected target %q, got %q", i, want[i], m.Target)
+		}
+	}
+}
+
+func TestBuilder_ValidateErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "exists"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		b    *Builder
+		want ErrorReason
+	}{
+		{"absolute", NewBuilder().WithTmpfs("/tmp", ""), ReasonOutsideRoot},
+		{"escape", NewBuilder().WithTmpfs("w/../../tmp", ""), ReasonOutsideRoot},
+		{"root shadowed", NewBuilder().WithTmpfs("", "").WithTmpfs(".", "size=1m"), ReasonShadowed},
+		{"duplicate", NewBuilder().WithTmpfs("tmp", "").WithTmpfs("tmp/", ""), ReasonDuplicate},
+		{"shadowed", NewBuilder().WithBind("/src", "w", false).WithTmpfs("w", ""), ReasonShadowed},
+		{"readonly", NewBuilder().WithBind(tmpDir, "r", true).WithTmpfs("r/missing", ""), ReasonReadOnlyParent},
+		{"readonly proc", NewBuilder().WithProc().WithTmpfs("proc/x", ""), ReasonReadOnlyParent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.b.Validate()
+			var pe *PlanError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected PlanError, got %v", err)
+			}
+			if pe.Reason != tt.want {
+				t.Errorf("expected reason %v, got %v", tt.want, pe.Reason)
+			}
+		})
+	}
+}
+
+func TestBuilder_ValidateReadOnlyParentExists(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "exists"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	b := NewBuilder().
+		WithTmpfs("r/exists", "").
+		WithBind(tmpDir, "r", true)
+	if _, err := b.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBuilder_ValidateRoot(t *testing.T) {
+	b := NewBuilder().
+		WithTmpfs("tmp", "").
+		WithMount(Mount{Source: "/src"})
+	ms, err := b.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms[0].Target != "" {
+		t.Errorf("expected root mount first, got %q", ms[0].Target)
+	}
+}
+
+func TestBuilder_BuildRejectsInvalid(t *testing.T) {
+	b := NewBuilder().WithTmpfs("tmp", "").WithTmpfs("tmp", "size=1m")
+	if _, err := b.Build(); err == nil {
+		t.Errorf("expected error for shadowed mount")
+	}
+}
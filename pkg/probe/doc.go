@@ -0,0 +1,8 @@
+// Package probe detects the kernel features the sandbox relies on in the
+// current host, e.g. user namespaces, cgroup delegation, clone3 with
+// CLONE_INTO_CGROUP, pidfd, Landlock, memfd MFD_EXEC, seccomp user
+// notification, time namespaces and no_new_privs.
+//
+// The report recommends a runner for the host and explains the failure of a
+// forkexec.ChildError by the feature that is missing.
+package probe
@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"fmt"
+	"io"
+)
+
+// Feature is the name of a kernel feature that is probed
+type Feature string
+
+// Features probed by Probe
+const (
+	UserNamespace    Feature = "user_namespace"
+	CgroupV2         Feature = "cgroup_v2"
+	CgroupDelegation Feature = "cgroup_delegation"
+	CloneIntoCgroup  Feature = "clone_into_cgroup"
+	Pidfd            Feature = "pidfd"
+	Landlock         Feature = "landlock"
+	MemfdExec        Feature = "memfd_exec"
+	Seccomp          Feature = "seccomp"
+	SeccompUserNotif Feature = "seccomp_user_notif"
+	TimeNamespace    Feature = "time_namespace"
+	NoNewPrivs       Feature = "no_new_privs"
+	Ptrace           Feature = "ptrace"
+)
+
+// Result is the result of a single probe
+type Result struct {
+	Feature   Feature
+	Available bool
+	Detail    string // version, setting or the reason it is not available
+}
+
+// Report is the result of all probes on the current host
+type Report struct {
+	Kernel  string // kernel release
+	Root    bool   // probed with effective uid 0
+	Results []Result
+}
+
+// Recommendation is the runner recommended by the report
+type Recommendation struct {
+	Runner string   // ptrace, ns, container or empty if none works
+	Reason string   // why the runner was chosen
+	Hints  []string // optional features that could be enabled
+}
+
+// Get returns the result of the feature
+func (r *Report) Get(f Feature) (Result, bool) {
+	for _, res := range r.Results {
+		if res.Feature == f {
+			return res, true
+		}
+	}
+	return Result{Feature: f}, false
+}
+
+// Available returns whether the feature is available
+func (r *Report) Available(f Feature) bool {
+	res, _ := r.Get(f)
+	return res.Available
+}
+
+// Recommend recommends the runner for the host. The container runner is
+// preferred when user namespaces are available, otherwise ptrace
+func (r *Report) Recommend() Recommendation {
+	var rec Recommendation
+	switch {
+	case r.Available(UserNamespace) && r.Available(Seccomp) && r.Available(NoNewPrivs):
+		rec.Runner = "container"
+		rec.Reason = "user namespaces are available to isolate the file system, pids and network"
+	case r.Available(Ptrace) && r.Available(Seccomp):
+		rec.Runner = "ptrace"
+		rec.Reason = "user namespaces are not available, file access is checked by ptrace"
+	default:
+		rec.Reason = "neither user namespaces nor ptrace with seccomp is available"
+		return rec
+	}
+	if r.Available(CgroupDelegation) {
+		rec.Hints = append(rec.Hints, "cgroup is delegated: use -cgroup to account the resource usage")
+		if r.Available(CloneIntoCgroup) {
+			rec.Hints = append(rec.Hints, "clone3 supports CLONE_INTO_CGROUP: use -cgroupfd to start inside the cgroup")
+		}
+	}
+	if !r.Available(MemfdExec) {
+		if res, _ := r.Get(MemfdExec); res.Detail != "" {
+			rec.Hints = append(rec.Hints, "memfd may not be executable ("+res.Detail+"): -memfd may fail")
+		}
+	}
+	return rec
+}
+
+// WriteTo writes the report in human readable form
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	printf := func(format string, v ...any) error {
+		m, err := fmt.Fprintf(w, format, v...)
+		n += int64(m)
+		return err
+	}
+	if err := printf("kernel: %s (root: %v)\n", r.Kernel, r.Root); err != nil {
+		return n, err
+	}
+	for _, res := range r.Results {
+		mark := "no "
+		if res.Available {
+			mark = "yes"
+		}
+		if err := printf("  %-20s %s  %s\n", res.Feature, mark, res.Detail); err != nil {
+			return n, err
+		}
+	}
+	rec := r.Recommend()
+	runner := rec.Runner
+	if runner == "" {
+		runner = "none"
+	}
+	if err := printf("recommended runner: %s (%s)\n", runner, rec.Reason); err != nil {
+		return n, err
+	}
+	for _, h := range rec.Hints {
+		if err := printf("  hint: %s\n", h); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,344 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/criyle/go-sandbox/pkg/cgroup"
+	"github.com/criyle/go-sandbox/pkg/forkexec"
+	"golang.org/x/sys/unix"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// Probe probes all features on the current host
+func Probe() *Report {
+	r := &Report{
+		Kernel: kernelRelease(),
+		Root:   os.Geteuid() == 0,
+	}
+	for _, p := range []func() Result{
+		probeUserNamespace,
+		probeCgroupV2,
+		probeCgroupDelegation,
+		probeCloneIntoCgroup,
+		probePidfd,
+		probeLandlock,
+		probeMemfdExec,
+		probeSeccomp,
+		probeSeccompUserNotif,
+		probeTimeNamespace,
+		probeNoNewPrivs,
+		probePtrace,
+	} {
+		r.Results = append(r.Results, p())
+	}
+	return r
+}
+
+// Explain explains the forkexec.ChildError by the missing feature that
+// likely caused it. It returns empty string if nothing is related
+func (r *Report) Explain(err error) string {
+	ce, ok := childError(err)
+	if !ok {
+		return ""
+	}
+	var f Feature
+	switch ce.Location {
+	case forkexec.LocClone, forkexec.LocUnshareUserRead, forkexec.LocSetGroups,
+		forkexec.LocSetUid, forkexec.LocSetGid:
+		f = UserNamespace
+	case forkexec.LocMountRoot, forkexec.LocMountTmpfs, forkexec.LocMount,
+		forkexec.LocPivotRoot, forkexec.LocMountRootReadonly:
+		if !r.Available(UserNamespace) {
+			f = UserNamespace
+		}
+	case forkexec.LocSetNoNewPrivs:
+		f = NoNewPrivs
+	case forkexec.LocSeccomp:
+		f = Seccomp
+	case forkexec.LocPtraceMe, forkexec.LocStop:
+		f = Ptrace
+	}
+	res, _ := r.Get(f)
+	if f == "" || res.Available {
+		return ""
+	}
+	return fmt.Sprintf("%v: %s is not available: %s", err, f, res.Detail)
+}
+
+// childError finds the ChildError in err. Since runner results only keep the
+// error message, the location is parsed from the message otherwise
+func childError(err error) (forkexec.ChildError, bool) {
+	var ce forkexec.ChildError
+	if err == nil {
+		return ce, false
+	}
+	if errors.As(err, &ce) {
+		return ce, true
+	}
+	loc, _, ok := strings.Cut(err.Error(), ": ")
+	if !ok {
+		return ce, false
+	}
+	if i := strings.LastIndexByte(loc, '('); i > 0 && strings.HasSuffix(loc, ")") {
+		loc = loc[:i]
+	}
+	for l := forkexec.LocClone; l <= forkexec.LocExecve; l++ {
+		if l.String() == loc {
+			ce.Location = l
+			return ce, true
+		}
+	}
+	return ce, false
+}
+
+func kernelRelease() string {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return "unknown"
+	}
+	return unix.ByteSliceToString(u.Release[:])
+}
+
+// kernelVersion returns the major and minor version of the kernel
+func kernelVersion() (int, int) {
+	f := strings.FieldsFunc(kernelRelease(), func(r rune) bool { return r < '0' || r > '9' })
+	if len(f) < 2 {
+		return 0, 0
+	}
+	major, _ := strconv.Atoi(f[0])
+	minor, _ := strconv.Atoi(f[1])
+	return major, minor
+}
+
+func kernelAtLeast(major, minor int) bool {
+	ma, mi := kernelVersion()
+	return ma > major || ma == major && mi >= minor
+}
+
+// readSysctl reads the sysctl value by its /proc/sys path
+func readSysctl(name string) (string, bool) {
+	b, err := os.ReadFile(filepath.Join("/proc/sys", strings.ReplaceAll(name, ".", "/")))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(b)), true
+}
+
+func probeUserNamespace() Result {
+	res := Result{Feature: UserNamespace}
+	var restrictions []string
+	for _, n := range []string{
+		"kernel.unprivileged_userns_clone",
+		"user.max_user_namespaces",
+		"kernel.apparmor_restrict_unprivileged_userns",
+	} {
+		if v, ok := readSysctl(n); ok {
+			restrictions = append(restrictions, n+"="+v)
+		}
+	}
+	detail := strings.Join(restrictions, " ")
+
+	// start a process in a new user namespace with the current uid mapped
+	exe := ""
+	for _, p := range []string{"/bin/true", "/usr/bin/true"} {
+		if _, err := os.Stat(p); err == nil {
+			exe = p
+			break
+		}
+	}
+	if exe == "" {
+		res.Detail = "true(1) not found: " + detail
+		return res
+	}
+	cmd := exec.Command(exe)
+	cmd.Env = []string{}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID,
+		UidMappings: []syscall.SysProcIDMap{{HostID: os.Geteuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{HostID: os.Getegid(), Size: 1}},
+	}
+	if err := cmd.Run(); err != nil {
+		res.Detail = strings.TrimSpace(err.Error() + " " + detail)
+		return res
+	}
+	res.Available = true
+	res.Detail = detail
+	return res
+}
+
+func probeCgroupV2() Result {
+	res := Result{Feature: CgroupV2}
+	if cgroup.DetectType() != cgroup.TypeV2 {
+		res.Detail = "cgroup v1 or not mounted at " + cgroupRoot
+		return res
+	}
+	res.Available = true
+	if ct, err := cgroup.GetAvailableControllerV2(); err == nil {
+		res.Detail = "controllers: " + ct.String()
+	}
+	return res
+}
+
+func probeCgroupDelegation() Result {
+	res := Result{Feature: CgroupDelegation}
+	prefix, err := cgroup.GetCurrentCgroupPrefix()
+	if err != nil {
+		res.Detail = err.Error()
+		return res
+	}
+	var dir string
+	if cgroup.DetectType() == cgroup.TypeV2 {
+		dir = filepath.Join(cgroupRoot, prefix)
+	} else {
+		// the cgroup of memory controller decides whether it can be used
+		dir = filepath.Join(cgroupRoot, "memory", prefix)
+	}
+	if err := unix.Access(dir, unix.W_OK); err != nil {
+		res.Detail = fmt.Sprintf("%s: %v", dir, err)
+		return res
+	}
+	res.Available = true
+	ct, err := cgroup.GetAvailableControllerWithPrefix(prefix)
+	if err != nil {
+		res.Detail = dir
+		return res
+	}
+	res.Detail = fmt.Sprintf("%s controllers: %s", dir, ct)
+	return res
+}
+
+func probeCloneIntoCgroup() Result {
+	res := Result{Feature: CloneIntoCgroup}
+	// clone3 with zero size fails with EINVAL without creating any process
+	_, _, errno := unix.Syscall(unix.SYS_CLONE3, 0, 0, 0)
+	switch {
+	case errno == unix.ENOSYS:
+		res.Detail = "clone3 not supported (kernel >= 5.3)"
+	case !kernelAtLeast(5, 7):
+		res.Detail = "CLONE_INTO_CGROUP not supported (kernel >= 5.7)"
+	case cgroup.DetectType() != cgroup.TypeV2:
+		res.Detail = "requires cgroup v2"
+	default:
+		res.Available = true
+	}
+	return res
+}
+
+func probePidfd() Result {
+	res := Result{Feature: Pidfd}
+	fd, err := unix.PidfdOpen(os.Getpid(), 0)
+	if err != nil {
+		res.Detail = "pidfd_open: " + err.Error() + " (kernel >= 5.3)"
+		return res
+	}
+	unix.Close(fd)
+	res.Available = true
+	return res
+}
+
+func probeLandlock() Result {
+	res := Result{Feature: Landlock}
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		res.Detail = "landlock_create_ruleset: " + errno.Error()
+		return res
+	}
+	res.Available = true
+	res.Detail = "ABI " + strconv.Itoa(int(abi))
+	return res
+}
+
+func probeMemfdExec() Result {
+	res := Result{Feature: MemfdExec}
+	noexec, _ := readSysctl("vm.memfd_noexec")
+	fd, err := unix.MemfdCreate("probe", unix.MFD_CLOEXEC|unix.MFD_EXEC)
+	if err != nil {
+		// MFD_EXEC is not known before kernel 6.3, memfd is executable by default
+		if err == unix.EINVAL && !kernelAtLeast(6, 3) {
+			res.Available = true
+			res.Detail = "MFD_EXEC not supported, memfd is executable by default"
+			return res
+		}
+		res.Detail = "memfd_create: " + err.Error()
+		if noexec != "" {
+			res.Detail += " vm.memfd_noexec=" + noexec
+		}
+		return res
+	}
+	unix.Close(fd)
+	res.Available = true
+	if noexec != "" {
+		res.Detail = "vm.memfd_noexec=" + noexec
+	}
+	return res
+}
+
+func probeSeccomp() Result {
+	res := Result{Feature: Seccomp}
+	if _, err := unix.PrctlRetInt(unix.PR_GET_SECCOMP, 0, 0, 0, 0); err != nil {
+		res.Detail = "prctl(PR_GET_SECCOMP): " + err.Error()
+		return res
+	}
+	res.Available = true
+	return res
+}
+
+func probeSeccompUserNotif() Result {
+	res := Result{Feature: SeccompUserNotif}
+	action := uint32(unix.SECCOMP_RET_USER_NOTIF)
+	_, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_GET_ACTION_AVAIL, 0, uintptr(unsafe.Pointer(&action)))
+	if errno != 0 {
+		res.Detail = "SECCOMP_RET_USER_NOTIF: " + errno.Error() + " (kernel >= 5.0)"
+		return res
+	}
+	res.Available = true
+	return res
+}
+
+func probeTimeNamespace() Result {
+	res := Result{Feature: TimeNamespace}
+	if _, err := os.Stat("/proc/self/ns/time"); err != nil {
+		res.Detail = err.Error() + " (kernel >= 5.6)"
+		return res
+	}
+	res.Available = true
+	return res
+}
+
+func probeNoNewPrivs() Result {
+	res := Result{Feature: NoNewPrivs}
+	// setting no_new_privs is irreversible, so only check the prctl exists
+	v, err := unix.PrctlRetInt(unix.PR_GET_NO_NEW_PRIVS, 0, 0, 0, 0)
+	if err != nil {
+		res.Detail = "prctl(PR_GET_NO_NEW_PRIVS): " + err.Error() + " (kernel >= 3.5)"
+		return res
+	}
+	res.Available = true
+	res.Detail = "current: " + strconv.Itoa(v)
+	return res
+}
+
+func probePtrace() Result {
+	res := Result{Feature: Ptrace, Available: true}
+	// yama scope 1 allows tracing children with PTRACE_TRACEME, scope 2
+	// requires CAP_SYS_PTRACE and scope 3 disables ptrace
+	if v, ok := readSysctl("kernel.yama.ptrace_scope"); ok {
+		res.Detail = "kernel.yama.ptrace_scope=" + v
+		switch v {
+		case "2":
+			res.Available = os.Geteuid() == 0
+		case "3":
+			res.Available = false
+		}
+	}
+	return res
+}
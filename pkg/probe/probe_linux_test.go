@@ -0,0 +1,44 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/criyle/go-sandbox/pkg/forkexec"
+)
+
+func TestProbe(t *testing.T) {
+	r := Probe()
+	for _, f := range []Feature{
+		UserNamespace, CgroupV2, CgroupDelegation, CloneIntoCgroup, Pidfd, Landlock,
+		MemfdExec, Seccomp, SeccompUserNotif, TimeNamespace, NoNewPrivs, Ptrace,
+	} {
+		res, ok := r.Get(f)
+		if !ok {
+			t.Errorf("%s not probed", f)
+		}
+		t.Logf("%s: %v %s", f, res.Available, res.Detail)
+	}
+	// no_new_privs exists since 3.5
+	if !r.Available(NoNewPrivs) {
+		t.Errorf("expected no_new_privs to be available")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	err := fmt.Errorf("start: %w", forkexec.ChildError{Err: syscall.EPERM, Location: forkexec.LocClone})
+	if s := report(Seccomp).Explain(err); s == "" {
+		t.Errorf("expected clone failure to be explained by user namespace")
+	}
+	if s := report(UserNamespace).Explain(err); s != "" {
+		t.Errorf("expected no explanation with user namespace, got %q", s)
+	}
+	if s := report(UserNamespace).Explain(errors.New("seccomp: invalid argument")); s == "" {
+		t.Errorf("expected seccomp failure message to be explained")
+	}
+	if s := report().Explain(syscall.EPERM); s != "" {
+		t.Errorf("expected no explanation for non child error, got %q", s)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package probe
+
+import "runtime"
+
+// Probe reports all features as not available on platforms other than linux
+func Probe() *Report {
+	r := &Report{Kernel: runtime.GOOS}
+	for _, f := range []Feature{
+		UserNamespace, CgroupV2, CgroupDelegation, CloneIntoCgroup, Pidfd, Landlock,
+		MemfdExec, Seccomp, SeccompUserNotif, TimeNamespace, NoNewPrivs, Ptrace,
+	} {
+		r.Results = append(r.Results, Result{Feature: f, Detail: "unsupported on " + runtime.GOOS})
+	}
+	return r
+}
+
+// Explain returns empty string on platforms other than linux
+func (r *Report) Explain(err error) string {
+	return ""
+}
@@ -0,0 +1,52 @@
+package probe
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func report(fs ...Feature) *Report {
+	r := &Report{}
+	for _, f := range fs {
+		r.Results = append(r.Results, Result{Feature: f, Available: true})
+	}
+	return r
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *Report
+		runner string
+		hints  int
+	}{
+		{"container", report(UserNamespace, Seccomp, NoNewPrivs, Ptrace, MemfdExec), "container", 0},
+		{"ptrace", report(Seccomp, NoNewPrivs, Ptrace, MemfdExec), "ptrace", 0},
+		{"none", report(NoNewPrivs, MemfdExec), "", 0},
+		{"cgroup", report(UserNamespace, Seccomp, NoNewPrivs, CgroupDelegation, CloneIntoCgroup, MemfdExec), "container", 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := tc.report.Recommend()
+			if rec.Runner != tc.runner {
+				t.Errorf("expected runner %q, got %q", tc.runner, rec.Runner)
+			}
+			if len(rec.Hints) != tc.hints {
+				t.Errorf("expected %d hints, got %v", tc.hints, rec.Hints)
+			}
+		})
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := report(Seccomp, Ptrace).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"seccomp", "recommended runner: ptrace"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in report, got %s", s, buf.String())
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/criyle/go-sandbox/runner"
 )
@@ -120,3 +121,36 @@ func (r RLimits) String() string {
 	sb.WriteString("]")
 	return sb.String()
 }
+
+// ApplyLimit appends the rlimit for the output limit defined by the runner
+// limit unless it is already present. The process limit is not applied as
+// RLIMIT_NPROC since it counts all processes of the real user, is ignored for
+// root and never reports StatusProcessLimitExceeded (use cgroup pids.max)
+func ApplyLimit(rlimits []RLimit, l runner.Limit) []RLimit {
+	has := func(res int) bool {
+		for _, r := range rlimits {
+			if r.Res == res {
+				return true
+			}
+		}
+		return false
+	}
+	if l.OutputLimit > 0 && !has(syscall.RLIMIT_FSIZE) {
+		rlimits = append(rlimits, RLimit{
+			Res:  syscall.RLIMIT_FSIZE,
+			Rlim: getRlimit(uint64(l.OutputLimit), uint64(l.OutputLimit)),
+		})
+	}
+	return rlimits
+}
+
+// CPUHardLimitExceeded returns true if the cpu time reaches the hard RLIMIT_CPU
+// so that the kernel killed the process by SIGKILL
+func CPUHardLimitExceeded(rlimits []RLimit, cpu time.Duration) bool {
+	for _, r := range rlimits {
+		if r.Res == syscall.RLIMIT_CPU {
+			return cpu >= time.Duration(r.Rlim.Max)*time.Second
+		}
+	}
+	return false
+}
@@ -5,6 +5,8 @@ package rlimit
 import (
 	"syscall"
 	"testing"
+
+	"github.com/criyle/go-sandbox/runner"
 )
 
 func TestPrepareRLimit(t *testing.T) {
@@ -134,3 +136,14 @@ func TestRLimitsString_Empty(t *testing.T) {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+func TestApplyLimit(t *testing.T) {
+	rl := RLimits{FileSize: 1024}
+	rls := ApplyLimit(rl.PrepareRLimit(), runner.Limit{OutputLimit: 2048, ProcessLimit: 4})
+	if len(rls) != 1 {
+		t.Fatalf("expected 1 rlimit, got %v", rls)
+	}
+	if rls[0].Res != syscall.RLIMIT_FSIZE || rls[0].Rlim.Cur != 1024 {
+		t.Errorf("expected existing file size limit kept, got %v", rls[0])
+	}
+}
@@ -23,7 +23,7 @@ func processVMReadv(pid int, localIov, remoteIov []unix.Iovec,
 func vmRead(pid int, addr uintptr, buff []byte) (int, error) {
 	l := len(buff)
 	localIov := getIovecs(&buff[0], l)
-	remoteIov := getIovecs((*byte)(unsafe.Pointer(addr)), l)
+	remoteIov := getRemoteIovecs(addr, l)
 	n, _, err := processVMReadv(pid, localIov, remoteIov, uintptr(0))
 	if err == 0 {
 		return int(n), nil
@@ -31,10 +31,38 @@ func vmRead(pid int, addr uintptr, buff []byte) (int, error) {
 	return int(n), err
 }
 
+func processVMWritev(pid int, localIov, remoteIov []unix.Iovec,
+	flags uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	return syscall.Syscall6(unix.SYS_PROCESS_VM_WRITEV, uintptr(pid),
+		uintptr(unsafe.Pointer(&localIov[0])), uintptr(len(localIov)),
+		uintptr(unsafe.Pointer(&remoteIov[0])), uintptr(len(remoteIov)),
+		flags)
+}
+
+func vmWrite(pid int, addr uintptr, buff []byte) (int, error) {
+	l := len(buff)
+	localIov := getIovecs(&buff[0], l)
+	remoteIov := getRemoteIovecs(addr, l)
+	n, _, err := processVMWritev(pid, localIov, remoteIov, uintptr(0))
+	if err == 0 {
+		return int(n), nil
+	}
+	return int(n), err
+}
+
 func getIovecs(base *byte, l int) []unix.Iovec {
 	return []unix.Iovec{getIovec(base, l)}
 }
 
+// getRemoteIovecs returns the iovec of the memory in the tracee. The address
+// is not a Go pointer, so it is stored into Base as uintptr rather than
+// converted by unsafe.Pointer(addr)
+func getRemoteIovecs(addr uintptr, l int) []unix.Iovec {
+	iov := getIovec(nil, l)
+	*(*uintptr)(unsafe.Pointer(&iov.Base)) = addr
+	return []unix.Iovec{iov}
+}
+
 func vmReadStr(pid int, addr uintptr, buff []byte) error {
 	// Handle unaligned address: calculate remaining bytes to page boundary
 	totalRead := 0 // Total bytes read so far
@@ -3,6 +3,8 @@ package ptracer
 import (
 	"os"
 	"syscall"
+
+	unix "golang.org/x/sys/unix"
 )
 
 // Context is the context for current syscall trap
@@ -10,8 +12,19 @@ import (
 type Context struct {
 	// Pid is current context process pid
 	Pid int
-	// current reg context (platform dependent)
-	regs syscall.PtraceRegs
+	// syscall number, arguments, audit arch and instruction pointer
+	nr   uint
+	args [6]uint
+	arch uint32
+	ip   uint
+	// current reg context (platform dependent), loaded on demand if the
+	// syscall is decoded by PTRACE_GET_SYSCALL_INFO
+	regs       syscall.PtraceRegs
+	regsLoaded bool
+	// exit is true if the context is at the syscall exit
+	exit bool
+	// scratch is the size of stack used by SetArgString below the red zone
+	scratch uint
 }
 
 var (
@@ -19,23 +32,163 @@ var (
 	// initial true and becomes false if tried and failed with ENOSYS
 	UseVMReadv = true
 	pageSize   = 4 << 10
+
+	// useSyscallInfo becomes false if PTRACE_GET_SYSCALL_INFO is not
+	// supported (kernel < 5.3)
+	useSyscallInfo = true
 )
 
+// redZoneSize is the stack size below the stack pointer that SetArgString
+// keeps, larger than the red zone of all supported ABIs
+const redZoneSize = 512
+
 func init() {
 	pageSize = os.Getpagesize()
 }
 
+// getTrapContext gets the context at the seccomp stop. The syscall is decoded
+// by PTRACE_GET_SYSCALL_INFO if available and registers otherwise
 func getTrapContext(pid int) (*Context, error) {
-	var regs syscall.PtraceRegs
-	//err := syscall.PtraceGetRegs(pid, &regs)
-	err := ptraceGetRegSet(pid, &regs)
-	if err != nil {
+	c := &Context{Pid: pid}
+	if useSyscallInfo {
+		var info ptraceSyscallInfo
+		err := ptraceGetSyscallInfo(pid, &info)
+		switch {
+		case err == nil && info.Op == unix.PTRACE_SYSCALL_INFO_SECCOMP:
+			c.nr = uint(info.Nr)
+			for i, a := range info.Args {
+				c.args[i] = uint(a)
+			}
+			c.arch = info.Arch
+			c.ip = uint(info.InstructionPointer)
+			return c, nil
+		case err == syscall.EIO || err == syscall.EINVAL:
+			useSyscallInfo = false
+		}
+	}
+	if err := c.loadRegs(); err != nil {
 		return nil, err
 	}
-	return &Context{
+	c.nr, c.args = c.syscallRegs()
+	c.arch = c.syscallArch()
+	c.ip = c.instructionPointer()
+	return c, nil
+}
+
+// getExitContext gets the context at the syscall exit stop with the syscall
+// decoded at its entry
+func getExitContext(pid int, entry *Context) (*Context, error) {
+	c := &Context{
 		Pid:  pid,
-		regs: regs,
-	}, nil
+		nr:   entry.nr,
+		args: entry.args,
+		arch: entry.arch,
+		exit: true,
+	}
+	if err := c.loadRegs(); err != nil {
+		return nil, err
+	}
+	c.ip = c.instructionPointer()
+	return c, nil
+}
+
+// loadRegs reads the registers if not loaded
+func (c *Context) loadRegs() error {
+	if c.regsLoaded {
+		return nil
+	}
+	if err := ptraceGetRegSet(c.Pid, &c.regs); err != nil {
+		return err
+	}
+	c.regsLoaded = true
+	return nil
+}
+
+// setRegs writes the modified registers back to the tracee
+func (c *Context) setRegs() error {
+	if err := c.loadRegs(); err != nil {
+		return err
+	}
+	return ptraceSetRegSet(c.Pid, &c.regs)
+}
+
+// SyscallNo get current syscall no
+func (c *Context) SyscallNo() uint {
+	return c.nr
+}
+
+// Arg0 gets the arg0 for the current syscall
+func (c *Context) Arg0() uint {
+	return c.args[0]
+}
+
+// Arg1 gets the arg1 for the current syscall
+func (c *Context) Arg1() uint {
+	return c.args[1]
+}
+
+// Arg2 gets the arg2 for the current syscall
+func (c *Context) Arg2() uint {
+	return c.args[2]
+}
+
+// Arg3 gets the arg3 for the current syscall
+func (c *Context) Arg3() uint {
+	return c.args[3]
+}
+
+// Arg4 gets the arg4 for the current syscall
+func (c *Context) Arg4() uint {
+	return c.args[4]
+}
+
+// Arg5 gets the arg5 for the current syscall
+func (c *Context) Arg5() uint {
+	return c.args[5]
+}
+
+// Arch gets the audit arch (AUDIT_ARCH_*) of the current syscall. It differs
+// from the native arch for a compat syscall, e.g. int 0x80 on amd64, which
+// should not be resolved by the native syscall table. It is derived from the
+// registers if PTRACE_GET_SYSCALL_INFO is not available
+func (c *Context) Arch() uint32 {
+	return c.arch
+}
+
+// IsNativeArch returns whether the current syscall uses the native syscall table
+func (c *Context) IsNativeArch() bool {
+	return c.arch == nativeArch
+}
+
+// InstructionPointer gets the instruction pointer of the current syscall
+func (c *Context) InstructionPointer() uint {
+	return c.ip
+}
+
+// SetArg sets the nth (0-5) argument for the current syscall, it takes effect
+// with TraceRedirect
+func (c *Context) SetArg(n int, v uint) {
+	if n < 0 || n >= len(c.args) || c.loadRegs() != nil {
+		return
+	}
+	c.args[n] = v
+	c.setArgReg(n, v)
+}
+
+// SetReturnValue set the return value if skip the syscall or at the syscall exit
+func (c *Context) SetReturnValue(retval int) {
+	if c.loadRegs() != nil {
+		return
+	}
+	c.setReturnValueReg(retval)
+}
+
+// ReturnValue gets the return value at the syscall exit
+func (c *Context) ReturnValue() int {
+	if c.loadRegs() != nil {
+		return 0
+	}
+	return c.returnValueReg()
 }
 
 // GetString get the string from process data segment
@@ -56,3 +209,51 @@ func (c *Context) GetString(addr uintptr) string {
 	syscall.PtracePeekData(c.Pid, addr, buff)
 	return string(buff[:clen(buff)])
 }
+
+// WriteMemory writes data into the process memory at addr
+func (c *Context) WriteMemory(addr uintptr, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if UseVMReadv {
+		n, err := vmWrite(c.Pid, addr, data)
+		switch {
+		case err == nil && n == len(data):
+			return nil
+		case err == syscall.ENOSYS:
+			UseVMReadv = false
+		case err != nil:
+			return err
+		}
+	}
+	_, err := syscall.PtracePokeData(c.Pid, addr, data)
+	return err
+}
+
+// WriteString writes the null terminated string into the process memory at addr
+func (c *Context) WriteString(addr uintptr, s string) error {
+	return c.WriteMemory(addr, append([]byte(s), 0))
+}
+
+// SetArgString writes the string into the process stack below the red zone
+// and sets the nth argument to its address, so that a path argument can be
+// redirected by TraceRedirect. The string is only valid during the syscall.
+//
+// The kernel does not grow the stack for the remote write (since linux 6.5),
+// so it fails with EFAULT if that part of the stack was never touched. The
+// string also stays writable by other threads of the tracee until the kernel
+// reads it, so the redirect should not be relied on for access control of
+// multi-threaded tracees
+func (c *Context) SetArgString(n int, s string) error {
+	if err := c.loadRegs(); err != nil {
+		return err
+	}
+	sp := c.stackPointer() - redZoneSize
+	addr := (sp - c.scratch - uint(len(s)) - 1) &^ 15
+	c.scratch = sp - addr
+	if err := c.WriteString(uintptr(addr), s); err != nil {
+		return err
+	}
+	c.SetArg(n, addr)
+	return nil
+}
@@ -0,0 +1,75 @@
+package ptracer
+
+import (
+	unix "golang.org/x/sys/unix"
+)
+
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_I386
+
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	return uint(uint32(c.regs.Orig_eax)), [6]uint{
+		uint(uint32(c.regs.Ebx)),
+		uint(uint32(c.regs.Ecx)),
+		uint(uint32(c.regs.Edx)),
+		uint(uint32(c.regs.Esi)),
+		uint(uint32(c.regs.Edi)),
+		uint(uint32(c.regs.Ebp)),
+	}
+}
+
+// syscallArch gets the audit arch of the syscall from the registers
+func (c *Context) syscallArch() uint32 {
+	return nativeArch
+}
+
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(uint32(c.regs.Eip))
+}
+
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Ebx = int32(v)
+	case 1:
+		c.regs.Ecx = int32(v)
+	case 2:
+		c.regs.Edx = int32(v)
+	case 3:
+		c.regs.Esi = int32(v)
+	case 4:
+		c.regs.Edi = int32(v)
+	case 5:
+		c.regs.Ebp = int32(v)
+	}
+}
+
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(uint32(c.regs.Esp))
+}
+
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
+	c.regs.Eax = int32(retval)
+}
+
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int32(c.regs.Eax))
+}
+
+func (c *Context) skipSyscall() error {
+	c.regs.Orig_eax = -1
+	return ptraceSetRegSet(c.Pid, &c.regs)
+}
+
+func getIovec(base *byte, l int) unix.Iovec {
+	return unix.Iovec{
+		Base: base,
+		Len:  uint32(l),
+	}
+}
@@ -6,46 +6,77 @@ import (
 	unix "golang.org/x/sys/unix"
 )
 
-// SyscallNo get current syscall no
-func (c *Context) SyscallNo() uint {
-	return uint(c.regs.Orig_rax)
-}
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_X86_64
 
-// Arg0 gets the arg0 for the current syscall
-func (c *Context) Arg0() uint {
-	return uint(c.regs.Rdi)
-}
+// compatCS is the code segment selector of 32-bit user code
+const compatCS = 0x23
 
-// Arg1 gets the arg1 for the current syscall
-func (c *Context) Arg1() uint {
-	return uint(c.regs.Rsi)
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	return uint(c.regs.Orig_rax), [6]uint{
+		uint(c.regs.Rdi),
+		uint(c.regs.Rsi),
+		uint(c.regs.Rdx),
+		uint(c.regs.R10),
+		uint(c.regs.R8),
+		uint(c.regs.R9),
+	}
 }
 
-// Arg2 gets the arg2 for the current syscall
-func (c *Context) Arg2() uint {
-	return uint(c.regs.Rdx)
+// syscallArch gets the audit arch of the syscall from the registers. The
+// syscall from 32-bit code and int 0x80 from 64-bit code use the i386 table
+func (c *Context) syscallArch() uint32 {
+	if c.regs.Cs == compatCS {
+		return unix.AUDIT_ARCH_I386
+	}
+	// the instruction pointer is after the syscall instruction
+	insn := make([]byte, 2)
+	if _, err := syscall.PtracePeekText(c.Pid, uintptr(c.regs.Rip-2), insn); err == nil &&
+		insn[0] == 0xcd && insn[1] == 0x80 {
+		return unix.AUDIT_ARCH_I386
+	}
+	return nativeArch
 }
 
-// Arg3 gets the arg3 for the current syscall
-func (c *Context) Arg3() uint {
-	return uint(c.regs.R10)
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Rip)
 }
 
-// Arg4 gets the arg4 for the current syscall
-func (c *Context) Arg4() uint {
-	return uint(c.regs.R8)
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Rdi = uint64(v)
+	case 1:
+		c.regs.Rsi = uint64(v)
+	case 2:
+		c.regs.Rdx = uint64(v)
+	case 3:
+		c.regs.R10 = uint64(v)
+	case 4:
+		c.regs.R8 = uint64(v)
+	case 5:
+		c.regs.R9 = uint64(v)
+	}
 }
 
-// Arg5 gets the arg5 for the current syscall
-func (c *Context) Arg5() uint {
-	return uint(c.regs.R9)
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Rsp)
 }
 
-// SetReturnValue set the return value if skip the syscall
-func (c *Context) SetReturnValue(retval int) {
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
 	c.regs.Rax = uint64(retval)
 }
 
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int64(c.regs.Rax))
+}
+
 func (c *Context) skipSyscall() error {
 	c.regs.Orig_rax = ^uint64(0) //-1
 	return syscall.PtraceSetRegs(c.Pid, &c.regs)
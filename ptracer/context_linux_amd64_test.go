@@ -0,0 +1,109 @@
+package ptracer
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criyle/go-sandbox/pkg/forkexec"
+	"github.com/criyle/go-sandbox/pkg/seccomp/libseccomp"
+	"github.com/criyle/go-sandbox/runner"
+)
+
+type compatHandler struct {
+	allowHandler
+}
+
+func (compatHandler) Handle(ctx *Context) TraceAction {
+	if !ctx.IsNativeArch() {
+		return TraceKill
+	}
+	return TraceAllow
+}
+
+// writeCompatProgram writes a static executable that calls getpid by int 0x80
+// from 64-bit code and then exits
+func writeCompatProgram(t *testing.T) string {
+	t.Helper()
+	code := []byte{
+		0xb8, 0x14, 0x00, 0x00, 0x00, // mov eax, 20 (getpid of i386)
+		0xcd, 0x80, // int 0x80
+		0xb8, 0x3c, 0x00, 0x00, 0x00, // mov eax, 60 (exit)
+		0x31, 0xff, // xor edi, edi
+		0x0f, 0x05, // syscall
+	}
+	const (
+		base     = 0x400000
+		ehdrSize = 64 // sizeof(Elf64_Ehdr)
+		phdrSize = 56 // sizeof(Elf64_Phdr)
+		hdrSize  = ehdrSize + phdrSize
+	)
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Entry:     base + hdrSize,
+		Phoff:     ehdrSize,
+		Ehsize:    ehdrSize,
+		Phentsize: phdrSize,
+		Phnum:     1,
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	prog := elf.Prog64{
+		Type:   uint32(elf.PT_LOAD),
+		Flags:  uint32(elf.PF_R | elf.PF_X),
+		Vaddr:  base,
+		Paddr:  base,
+		Filesz: hdrSize + uint64(len(code)),
+		Memsz:  hdrSize + uint64(len(code)),
+		Align:  0x1000,
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, hdr)
+	binary.Write(&b, binary.LittleEndian, prog)
+	b.Write(code)
+	p := filepath.Join(t.TempDir(), "compat")
+	if err := os.WriteFile(p, b.Bytes(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCompatSyscall(t *testing.T) {
+	prog := writeCompatProgram(t)
+	b := libseccomp.Builder{
+		Default: libseccomp.ActionTrace,
+	}
+	filter, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		useSyscallInfo bool
+	}{
+		{name: "SyscallInfo", useSyscallInfo: true},
+		{name: "Registers", useSyscallInfo: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(v bool) { useSyscallInfo = v }(useSyscallInfo)
+			useSyscallInfo = tc.useSyscallInfo
+
+			tracer := newTestTracer(t, compatHandler{}, nil, prog)
+			tracer.Runner.(*forkexec.Runner).Seccomp = filter.SockFprog()
+			r := tracer.Trace(context.Background())
+			if r.Status != runner.StatusDisallowedSyscall {
+				t.Fatal(r.Status, r.Error, r)
+			}
+		})
+	}
+}
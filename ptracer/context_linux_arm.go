@@ -6,44 +6,63 @@ import (
 	unix "golang.org/x/sys/unix"
 )
 
-// SyscallNo get current syscall no
-func (c *Context) SyscallNo() uint {
-	return uint(c.regs.Uregs[7]) // R7
-}
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_ARM
 
-// Arg0 gets the arg0 for the current syscall
-func (c *Context) Arg0() uint {
-	return uint(c.regs.Uregs[17]) //Orig_R0
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	// R7 holds the syscall number
+	return uint(c.regs.Uregs[7]), [6]uint{
+		uint(c.regs.Uregs[17]), // Orig_R0
+		uint(c.regs.Uregs[1]),  // R1
+		uint(c.regs.Uregs[2]),  // R2
+		uint(c.regs.Uregs[3]),  // R3
+		uint(c.regs.Uregs[4]),  // R4
+		uint(c.regs.Uregs[5]),  // R5
+	}
 }
 
-// Arg1 gets the arg1 for the current syscall
-func (c *Context) Arg1() uint {
-	return uint(c.regs.Uregs[1]) // R1
+// syscallArch gets the audit arch of the syscall from the registers
+func (c *Context) syscallArch() uint32 {
+	return nativeArch
 }
 
-// Arg2 gets the arg2 for the current syscall
-func (c *Context) Arg2() uint {
-	return uint(c.regs.Uregs[2]) // R2
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Uregs[15]) // PC
 }
 
-// Arg3 gets the arg3 for the current syscall
-func (c *Context) Arg3() uint {
-	return uint(c.regs.Uregs[3]) // R3
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Uregs[0] = uint32(v)
+	case 1:
+		c.regs.Uregs[1] = uint32(v)
+	case 2:
+		c.regs.Uregs[2] = uint32(v)
+	case 3:
+		c.regs.Uregs[3] = uint32(v)
+	case 4:
+		c.regs.Uregs[4] = uint32(v)
+	case 5:
+		c.regs.Uregs[5] = uint32(v)
+	}
 }
 
-// Arg4 gets the arg4 for the current syscall
-func (c *Context) Arg4() uint {
-	return uint(c.regs.Uregs[4]) // R4
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Uregs[13]) // SP
 }
 
-// Arg5 gets the arg5 for the current syscall
-func (c *Context) Arg5() uint {
-	return uint(c.regs.Uregs[5]) //R5
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
+	c.regs.Uregs[0] = uint32(retval) // R0
 }
 
-// SetReturnValue set the return value if skip the syscall
-func (c *Context) SetReturnValue(retval int) {
-	c.regs.Uregs[0] = uint32(retval) // R0
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int32(c.regs.Uregs[0])) // R0
 }
 
 func (c *Context) skipSyscall() error {
@@ -4,46 +4,72 @@ import (
 	unix "golang.org/x/sys/unix"
 )
 
-// SyscallNo get current syscall no
-func (c *Context) SyscallNo() uint {
-	return uint(c.regs.Regs[8]) // R8
-}
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_AARCH64
 
-// Arg0 gets the arg0 for the current syscall
-func (c *Context) Arg0() uint {
-	return uint(c.regs.Regs[0]) //R0
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	// R8 holds the syscall number
+	return uint(c.regs.Regs[8]), [6]uint{
+		uint(c.regs.Regs[0]), // R0
+		uint(c.regs.Regs[1]), // R1
+		uint(c.regs.Regs[2]), // R2
+		uint(c.regs.Regs[3]), // R3
+		uint(c.regs.Regs[4]), // R4
+		uint(c.regs.Regs[5]), // R5
+	}
 }
 
-// Arg1 gets the arg1 for the current syscall
-func (c *Context) Arg1() uint {
-	return uint(c.regs.Regs[1]) // R1
-}
+// pstateMode32 is the PSR_MODE32_BIT of pstate set for AArch32 (compat) tasks
+const pstateMode32 = 0x10
 
-// Arg2 gets the arg2 for the current syscall
-func (c *Context) Arg2() uint {
-	return uint(c.regs.Regs[2]) // R2
+// syscallArch gets the audit arch of the syscall from the registers. The
+// syscall from an AArch32 task uses the arm table
+func (c *Context) syscallArch() uint32 {
+	if c.regs.Pstate&pstateMode32 != 0 {
+		return unix.AUDIT_ARCH_ARM
+	}
+	return nativeArch
 }
 
-// Arg3 gets the arg3 for the current syscall
-func (c *Context) Arg3() uint {
-	return uint(c.regs.Regs[3]) // R3
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Pc)
 }
 
-// Arg4 gets the arg4 for the current syscall
-func (c *Context) Arg4() uint {
-	return uint(c.regs.Regs[4]) // R4
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Regs[0] = uint64(v)
+	case 1:
+		c.regs.Regs[1] = uint64(v)
+	case 2:
+		c.regs.Regs[2] = uint64(v)
+	case 3:
+		c.regs.Regs[3] = uint64(v)
+	case 4:
+		c.regs.Regs[4] = uint64(v)
+	case 5:
+		c.regs.Regs[5] = uint64(v)
+	}
 }
 
-// Arg5 gets the arg5 for the current syscall
-func (c *Context) Arg5() uint {
-	return uint(c.regs.Regs[5]) //R5
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Sp)
 }
 
-// SetReturnValue set the return value if skip the syscall
-func (c *Context) SetReturnValue(retval int) {
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
 	c.regs.Regs[0] = uint64(retval) // R0
 }
 
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int64(c.regs.Regs[0])) // R0
+}
+
 func (c *Context) skipSyscall() error {
 	err := ptraceSetRegSet(c.Pid, &c.regs)
 	if err != nil {
@@ -0,0 +1,77 @@
+package ptracer
+
+import (
+	unix "golang.org/x/sys/unix"
+)
+
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_LOONGARCH64
+
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	// a0 is set to -ENOSYS on syscall entry, arg0 is kept in orig_a0
+	return uint(c.regs.Regs[11]), [6]uint{
+		uint(c.regs.Orig_a0),
+		uint(c.regs.Regs[5]),
+		uint(c.regs.Regs[6]),
+		uint(c.regs.Regs[7]),
+		uint(c.regs.Regs[8]),
+		uint(c.regs.Regs[9]),
+	}
+}
+
+// syscallArch gets the audit arch of the syscall from the registers
+func (c *Context) syscallArch() uint32 {
+	return nativeArch
+}
+
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Era)
+}
+
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Orig_a0 = uint64(v)
+	case 1:
+		c.regs.Regs[5] = uint64(v)
+	case 2:
+		c.regs.Regs[6] = uint64(v)
+	case 3:
+		c.regs.Regs[7] = uint64(v)
+	case 4:
+		c.regs.Regs[8] = uint64(v)
+	case 5:
+		c.regs.Regs[9] = uint64(v)
+	}
+}
+
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Regs[3]) // sp
+}
+
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
+	c.regs.Regs[4] = uint64(retval)
+}
+
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int64(c.regs.Regs[4])) // a0
+}
+
+func (c *Context) skipSyscall() error {
+	// syscall number -1 skips the syscall and keeps a0 as the return value
+	c.regs.Regs[11] = ^uint64(0) // -1
+	return ptraceSetRegSet(c.Pid, &c.regs)
+}
+
+func getIovec(base *byte, l int) unix.Iovec {
+	return unix.Iovec{
+		Base: base,
+		Len:  uint64(l),
+	}
+}
@@ -0,0 +1,91 @@
+package ptracer
+
+import (
+	unix "golang.org/x/sys/unix"
+)
+
+// crSO is the summary overflow bit of cr0 that flags a syscall error
+const crSO = 0x10000000
+
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_PPC64LE
+
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	// r3 is set to -ENOSYS before the seccomp stop, arg0 is kept in orig_gpr3
+	return uint(c.regs.Gpr[0]), [6]uint{
+		uint(c.regs.Orig_gpr3),
+		uint(c.regs.Gpr[4]),
+		uint(c.regs.Gpr[5]),
+		uint(c.regs.Gpr[6]),
+		uint(c.regs.Gpr[7]),
+		uint(c.regs.Gpr[8]),
+	}
+}
+
+// syscallArch gets the audit arch of the syscall from the registers
+func (c *Context) syscallArch() uint32 {
+	return nativeArch
+}
+
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Nip)
+}
+
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Orig_gpr3 = uint64(v)
+	case 1:
+		c.regs.Gpr[4] = uint64(v)
+	case 2:
+		c.regs.Gpr[5] = uint64(v)
+	case 3:
+		c.regs.Gpr[6] = uint64(v)
+	case 4:
+		c.regs.Gpr[7] = uint64(v)
+	case 5:
+		c.regs.Gpr[8] = uint64(v)
+	}
+}
+
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Gpr[1]) // r1
+}
+
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
+	if c.exit {
+		// errors are reported as positive errno with cr0.SO set at exit
+		c.regs.Ccr &^= crSO
+		if retval < 0 && retval >= -4095 {
+			c.regs.Ccr |= crSO
+			retval = -retval
+		}
+	}
+	c.regs.Gpr[3] = uint64(retval)
+}
+
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	if c.regs.Ccr&crSO != 0 {
+		return -int(int64(c.regs.Gpr[3]))
+	}
+	return int(int64(c.regs.Gpr[3]))
+}
+
+func (c *Context) skipSyscall() error {
+	// syscall number -1 skips the syscall and keeps r3 as the return value
+	c.regs.Gpr[0] = ^uint64(0) // -1
+	return ptraceSetRegSet(c.Pid, &c.regs)
+}
+
+func getIovec(base *byte, l int) unix.Iovec {
+	return unix.Iovec{
+		Base: base,
+		Len:  uint64(l),
+	}
+}
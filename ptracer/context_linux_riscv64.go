@@ -0,0 +1,76 @@
+package ptracer
+
+import (
+	unix "golang.org/x/sys/unix"
+)
+
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_RISCV64
+
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	return uint(c.regs.A7), [6]uint{
+		uint(c.regs.A0),
+		uint(c.regs.A1),
+		uint(c.regs.A2),
+		uint(c.regs.A3),
+		uint(c.regs.A4),
+		uint(c.regs.A5),
+	}
+}
+
+// syscallArch gets the audit arch of the syscall from the registers
+func (c *Context) syscallArch() uint32 {
+	return nativeArch
+}
+
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Pc)
+}
+
+// setArgReg sets the nth (0-5) argument register for the current syscall. Kernels that keep arg0 in orig_a0 ignore the change of a0
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.A0 = uint64(v)
+	case 1:
+		c.regs.A1 = uint64(v)
+	case 2:
+		c.regs.A2 = uint64(v)
+	case 3:
+		c.regs.A3 = uint64(v)
+	case 4:
+		c.regs.A4 = uint64(v)
+	case 5:
+		c.regs.A5 = uint64(v)
+	}
+}
+
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Sp)
+}
+
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
+	c.regs.A0 = uint64(retval)
+}
+
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int64(c.regs.A0))
+}
+
+func (c *Context) skipSyscall() error {
+	// syscall number -1 skips the syscall and keeps a0 as the return value
+	c.regs.A7 = ^uint64(0) // -1
+	return ptraceSetRegSet(c.Pid, &c.regs)
+}
+
+func getIovec(base *byte, l int) unix.Iovec {
+	return unix.Iovec{
+		Base: base,
+		Len:  uint64(l),
+	}
+}
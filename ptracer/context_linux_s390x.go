@@ -0,0 +1,78 @@
+package ptracer
+
+import (
+	unix "golang.org/x/sys/unix"
+)
+
+// nativeArch is the audit arch of the native syscalls
+const nativeArch = unix.AUDIT_ARCH_S390X
+
+// syscallRegs gets the syscall number and arguments from registers
+func (c *Context) syscallRegs() (uint, [6]uint) {
+	// gpr2 holds the syscall number on syscall entry, arg0 is kept in orig_gpr2
+	return uint(c.regs.Gprs[2]), [6]uint{
+		uint(c.regs.Orig_gpr2),
+		uint(c.regs.Gprs[3]),
+		uint(c.regs.Gprs[4]),
+		uint(c.regs.Gprs[5]),
+		uint(c.regs.Gprs[6]),
+		uint(c.regs.Gprs[7]),
+	}
+}
+
+// syscallArch gets the audit arch of the syscall from the registers
+func (c *Context) syscallArch() uint32 {
+	return nativeArch
+}
+
+// instructionPointer gets the instruction pointer of the tracee
+func (c *Context) instructionPointer() uint {
+	return uint(c.regs.Psw.Addr)
+}
+
+// setArgReg sets the nth (0-5) argument register for the current syscall
+func (c *Context) setArgReg(n int, v uint) {
+	switch n {
+	case 0:
+		c.regs.Orig_gpr2 = uint64(v)
+	case 1:
+		c.regs.Gprs[3] = uint64(v)
+	case 2:
+		c.regs.Gprs[4] = uint64(v)
+	case 3:
+		c.regs.Gprs[5] = uint64(v)
+	case 4:
+		c.regs.Gprs[6] = uint64(v)
+	case 5:
+		c.regs.Gprs[7] = uint64(v)
+	}
+}
+
+// stackPointer gets the stack pointer of the tracee
+func (c *Context) stackPointer() uint {
+	return uint(c.regs.Gprs[15]) // r15
+}
+
+// setReturnValueReg sets the return value register
+func (c *Context) setReturnValueReg(retval int) {
+	c.regs.Gprs[2] = uint64(retval)
+}
+
+// returnValueReg gets the return value register at the syscall exit
+func (c *Context) returnValueReg() int {
+	return int(int64(c.regs.Gprs[2]))
+}
+
+func (c *Context) skipSyscall() error {
+	// gpr2 holds both the syscall number and the return value. Writing gpr2
+	// at the syscall entry makes the kernel skip the syscall and return gpr2,
+	// so it is left as the value set by SetReturnValue
+	return ptraceSetRegSet(c.Pid, &c.regs)
+}
+
+func getIovec(base *byte, l int) unix.Iovec {
+	return unix.Iovec{
+		Base: base,
+		Len:  uint64(l),
+	}
+}
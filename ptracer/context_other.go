@@ -39,6 +39,38 @@ func (c *Context) SetReturnValue(retval int) {
 
 }
 
+func (c *Context) ReturnValue() int {
+	return 0
+}
+
 func (c *Context) GetString(addr uintptr) string {
 	return ""
 }
+
+func (c *Context) SetArg(n int, v uint) {
+
+}
+
+func (c *Context) WriteMemory(addr uintptr, data []byte) error {
+	return nil
+}
+
+func (c *Context) WriteString(addr uintptr, s string) error {
+	return nil
+}
+
+func (c *Context) SetArgString(n int, s string) error {
+	return nil
+}
+
+func (c *Context) Arch() uint32 {
+	return 0
+}
+
+func (c *Context) IsNativeArch() bool {
+	return true
+}
+
+func (c *Context) InstructionPointer() uint {
+	return 0
+}
@@ -0,0 +1,37 @@
+package ptracer
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// logger returns the Logger of the tracer. If it is not set, logs are written
+// to Handler.Debug
+func (t *Tracer) logger() *slog.Logger {
+	switch {
+	case t.Logger != nil:
+		return t.Logger
+	case t.Handler != nil:
+		return slog.New(slog.NewTextHandler(debugWriter{t.Handler}, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+	default:
+		return slog.New(slog.DiscardHandler)
+	}
+}
+
+// debugWriter writes each log line to Handler.Debug
+type debugWriter struct {
+	Handler
+}
+
+func (w debugWriter) Write(b []byte) (int, error) {
+	w.Debug(strings.TrimSuffix(string(b), "\n"))
+	return len(b), nil
+}
@@ -3,6 +3,8 @@ package ptracer
 import (
 	"syscall"
 	"unsafe"
+
+	unix "golang.org/x/sys/unix"
 )
 
 // ptrace constants
@@ -13,6 +15,20 @@ const (
 	PTRACE_SET_SYSCALL = 23
 )
 
+// ptraceSyscallInfo is struct ptrace_syscall_info with the seccomp member of
+// the union
+type ptraceSyscallInfo struct {
+	Op                 uint8
+	_                  [3]uint8
+	Arch               uint32
+	InstructionPointer uint64
+	StackPointer       uint64
+	Nr                 uint64
+	Args               [6]uint64
+	RetData            uint32
+	_                  uint32
+}
+
 func ptrace(request int, pid int, addr uintptr, data uintptr) (err error) {
 	_, _, e1 := syscall.Syscall6(syscall.SYS_PTRACE, uintptr(request), uintptr(pid), uintptr(addr), uintptr(data), 0, 0)
 	if e1 != 0 {
@@ -31,6 +47,14 @@ func ptraceSetRegSet(pid int, regs *syscall.PtraceRegs) error {
 	return ptrace(syscall.PTRACE_SETREGSET, pid, NT_PRSTATUS, uintptr(unsafe.Pointer(&iov)))
 }
 
+func ptraceGetSyscallInfo(pid int, info *ptraceSyscallInfo) error {
+	return ptrace(unix.PTRACE_GET_SYSCALL_INFO, pid, unsafe.Sizeof(*info), uintptr(unsafe.Pointer(info)))
+}
+
+func ptraceListen(pid int) error {
+	return ptrace(unix.PTRACE_LISTEN, pid, 0, 0)
+}
+
 func ptraceArm64SetSyscall(pid int, syscallNo int) error {
 	iov := getIovec((*byte)(unsafe.Pointer(&syscallNo)), int(unsafe.Sizeof(syscallNo)))
 	return ptrace(syscall.PTRACE_SETREGSET, pid, NT_ARM_SYSTEM_CALL, uintptr(unsafe.Pointer(&iov)))
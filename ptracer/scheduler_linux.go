@@ -0,0 +1,312 @@
+package ptracer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+
+	unix "golang.org/x/sys/unix"
+
+	"github.com/criyle/go-sandbox/runner"
+)
+
+var errSchedulerClosed = errors.New("ptracer: scheduler closed")
+
+// Scheduler multiplexes traced programs onto a fixed pool of tracer threads.
+// Tracer.Trace locks one OS thread per run, while the Scheduler threads are
+// locked for their lifetime. Each thread starts the tracees assigned to it
+// (so it becomes their tracer) and waits for all of them by wait4(-1).
+// At most threads * perThread programs are traced at the same time, and
+// further runs wait in the queue
+type Scheduler struct {
+	perThread int
+	queue     chan *job
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// job is a queued Trace call
+type job struct {
+	ctx    context.Context
+	tracer *Tracer
+	result chan runner.Result
+}
+
+// NewScheduler starts a Scheduler with the number of tracer threads, each
+// traces up to perThread programs concurrently. Non-positive values default
+// to GOMAXPROCS threads and 1 program per thread
+func NewScheduler(threads, perThread int) *Scheduler {
+	if threads <= 0 {
+		threads = runtime.GOMAXPROCS(0)
+	}
+	if perThread <= 0 {
+		perThread = 1
+	}
+	s := &Scheduler{
+		perThread: perThread,
+		queue:     make(chan *job),
+		done:      make(chan struct{}),
+	}
+	s.wg.Add(threads)
+	for range threads {
+		go s.worker()
+	}
+	return s
+}
+
+// Trace queues the tracer to run on one of the tracer threads and waits for
+// its result. The run is not started if the context is done while queuing
+func (s *Scheduler) Trace(c context.Context, t *Tracer) runner.Result {
+	j := &job{ctx: c, tracer: t, result: make(chan runner.Result, 1)}
+	select {
+	case s.queue <- j:
+	case <-c.Done():
+		return runner.Result{
+			Status: runner.StatusRunnerError,
+			Error:  c.Err().Error(),
+		}
+	case <-s.done:
+		return runner.Result{
+			Status: runner.StatusRunnerError,
+			Error:  errSchedulerClosed.Error(),
+		}
+	}
+	return <-j.result
+}
+
+// Close kills the running programs and stops all tracer threads
+func (s *Scheduler) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.wg.Wait()
+}
+
+// scheduled is a run traced by a tracer thread
+type scheduled struct {
+	*tracing
+	done chan runner.Result
+}
+
+// tracerThread is the state of a Scheduler thread
+type tracerThread struct {
+	runs    map[int]*scheduled // pgid -> run
+	pids    map[int]*scheduled // tracee pid -> run
+	pending map[int][]waited   // events of new tracees before the fork event of their parent
+	ready   []waited           // pending events of new tracees to replay
+}
+
+// waited is a wait4 result of a tracee
+type waited struct {
+	pid     int
+	wstatus unix.WaitStatus
+	rusage  unix.Rusage
+}
+
+func (s *Scheduler) worker() {
+	defer s.wg.Done()
+
+	// the thread is the tracer of all its tracees. It is never unlocked so
+	// it exits together with the goroutine
+	runtime.LockOSThread()
+
+	// tracee stops are notified by SIGCHLD since wait4 could not select with
+	// the queue
+	kick := subscribeSIGCHLD()
+	defer unsubscribeSIGCHLD(kick)
+
+	w := &tracerThread{
+		runs:    make(map[int]*scheduled),
+		pids:    make(map[int]*scheduled),
+		pending: make(map[int][]waited),
+	}
+	for {
+		w.poll()
+
+		queue, notify := s.queue, kick
+		if len(w.runs) >= s.perThread {
+			queue = nil
+		}
+		if len(w.runs) == 0 {
+			notify = nil
+		}
+		select {
+		case j := <-queue:
+			w.start(j)
+		case <-notify:
+		case <-s.done:
+			w.stop()
+			return
+		}
+	}
+}
+
+// start starts the tracee of the job on the current thread
+func (w *tracerThread) start(j *job) {
+	t := j.tracer
+	log := t.logger()
+	pgid, err := t.Runner.Start()
+	if err != nil {
+		log.Debug("start tracee failed", "err", err)
+		j.result <- runner.Result{
+			Status: runner.StatusRunnerError,
+			Error:  err.Error(),
+		}
+		return
+	}
+	log.Debug("tracer started", "pid", pgid)
+	r := &scheduled{
+		tracing: t.newTracing(j.ctx, log, pgid),
+		done:    j.result,
+	}
+	r.newTask = func(pid int) { w.add(r, pid) }
+	w.runs[pgid] = r
+	w.pids[pgid] = r
+}
+
+// add registers the new tracee reported by the fork / clone event of its
+// parent and queues its events received before to replay
+func (w *tracerThread) add(r *scheduled, pid int) {
+	w.pids[pid] = r
+	if p, ok := w.pending[pid]; ok {
+		w.ready = append(w.ready, p...)
+		delete(w.pending, pid)
+	}
+}
+
+// poll handles all pending events of the tracees of the current thread
+func (w *tracerThread) poll() {
+	for len(w.runs) > 0 {
+		w.replay()
+
+		var e waited
+		// __WNOTHREAD excludes tracees of other tracer threads
+		pid, err := unix.Wait4(-1, &e.wstatus, unix.WALL|unix.WNOTHREAD|unix.WNOHANG, &e.rusage)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return
+		}
+		e.pid = pid
+		// new tracee could report its first stop before the fork event of its
+		// parent, so its events are kept until the event registers it
+		if _, ok := w.pids[pid]; !ok {
+			w.pending[pid] = append(w.pending[pid], e)
+			continue
+		}
+		w.dispatch(e)
+	}
+	// events of tracees never registered (e.g. get event message failed)
+	// are dropped once all runs are finished
+	clear(w.pending)
+	w.ready = nil
+}
+
+// replay handles the events of the registered new tracees in order
+func (w *tracerThread) replay() {
+	for len(w.ready) > 0 {
+		e := w.ready[0]
+		w.ready = w.ready[1:]
+		w.dispatch(e)
+	}
+}
+
+// dispatch handles the event by the run of the tracee, the event is dropped
+// if the run has finished
+func (w *tracerThread) dispatch(e waited) {
+	r, ok := w.pids[e.pid]
+	if e.wstatus.Exited() || e.wstatus.Signaled() {
+		delete(w.pids, e.pid)
+	}
+	if !ok {
+		return
+	}
+	if r.handleEvent(e.pid, e.wstatus, e.rusage) {
+		w.finish(r)
+	}
+}
+
+func (w *tracerThread) finish(r *scheduled) {
+	delete(w.runs, r.pgid)
+	for pid, p := range w.pids {
+		if p == r {
+			delete(w.pids, pid)
+		}
+	}
+	r.done <- r.finish()
+}
+
+// stop kills all the runs when the scheduler is closed
+func (w *tracerThread) stop() {
+	for _, r := range w.runs {
+		if r.result.Status == runner.StatusNormal {
+			r.result.Status = runner.StatusRunnerError
+			r.result.Error = errSchedulerClosed.Error()
+		}
+		w.finish(r)
+	}
+	clear(w.pending)
+	w.ready = nil
+}
+
+// handleEvent handles the event and recovers the panic of the handler
+func (tr *tracing) handleEvent(pid int, wstatus unix.WaitStatus, rusage unix.Rusage) (finished bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			tr.panicked(err)
+			finished = true
+		}
+	}()
+	return tr.event(pid, wstatus, rusage)
+}
+
+// sigchld broadcasts SIGCHLD to the subscribed tracer threads. The signal is
+// only notified while there are subscribers so that it is restored to the
+// default once all schedulers are closed
+var sigchld struct {
+	mu   sync.Mutex
+	c    chan os.Signal
+	subs map[chan struct{}]bool
+}
+
+func subscribeSIGCHLD() chan struct{} {
+	sigchld.mu.Lock()
+	defer sigchld.mu.Unlock()
+	if sigchld.c == nil {
+		sigchld.subs = make(map[chan struct{}]bool)
+		sigchld.c = make(chan os.Signal, 1)
+		signal.Notify(sigchld.c, unix.SIGCHLD)
+		go broadcastSIGCHLD(sigchld.c)
+	}
+	ch := make(chan struct{}, 1)
+	sigchld.subs[ch] = true
+	return ch
+}
+
+func unsubscribeSIGCHLD(ch chan struct{}) {
+	sigchld.mu.Lock()
+	defer sigchld.mu.Unlock()
+	delete(sigchld.subs, ch)
+	if len(sigchld.subs) == 0 && sigchld.c != nil {
+		// no more signal is sent to c after Stop returns
+		signal.Stop(sigchld.c)
+		close(sigchld.c)
+		sigchld.c = nil
+	}
+}
+
+func broadcastSIGCHLD(c chan os.Signal) {
+	for range c {
+		sigchld.mu.Lock()
+		for ch := range sigchld.subs {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+		sigchld.mu.Unlock()
+	}
+}
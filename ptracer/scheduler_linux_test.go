@@ -0,0 +1,81 @@
+package ptracer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/criyle/go-sandbox/runner"
+)
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(2, 2)
+	defer s.Close()
+
+	newTracer := func(args ...string) *Tracer {
+		return newTestTracer(t, allowHandler{}, []string{"openat"}, args...)
+	}
+
+	// the sleeping run should not block the runs on the same thread
+	sleep := make(chan runner.Result, 1)
+	go func() {
+		c, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		sleep <- s.Trace(c, newTracer("/bin/sleep", "10"))
+	}()
+
+	var wg sync.WaitGroup
+	results := make([]runner.Result, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.Trace(context.Background(), newTracer("/bin/sh", "-c", "/bin/true; /bin/true"))
+		}()
+	}
+	wg.Wait()
+	for i, r := range results {
+		if r.Status != runner.StatusNormal {
+			t.Fatal(i, r.Status, r.Error, r)
+		}
+	}
+	select {
+	case r := <-sleep:
+		t.Fatal("expected sleep still running", r)
+	default:
+	}
+	if r := <-sleep; r.Cause != runner.CauseCancelled {
+		t.Fatal(r.Status, r.Cause, r)
+	}
+}
+
+func TestSchedulerClosed(t *testing.T) {
+	s := NewScheduler(1, 1)
+	s.Close()
+
+	r := s.Trace(context.Background(), &Tracer{})
+	if r.Status != runner.StatusRunnerError || r.Error != errSchedulerClosed.Error() {
+		t.Fatal(r.Status, r)
+	}
+}
+
+func TestSchedulerSIGCHLD(t *testing.T) {
+	for range 2 {
+		s := NewScheduler(2, 1)
+		r := s.Trace(context.Background(), newTestTracer(t, allowHandler{}, []string{"openat"},
+			"/bin/sh", "-c", "/bin/true & /bin/true & wait"))
+		if r.Status != runner.StatusNormal {
+			t.Fatal(r.Status, r.Error, r)
+		}
+		s.Close()
+
+		// the signal is released after the last scheduler is closed
+		sigchld.mu.Lock()
+		c := sigchld.c
+		sigchld.mu.Unlock()
+		if c != nil {
+			t.Fatal("expected SIGCHLD notification stopped")
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package ptracer
 
-import "github.com/criyle/go-sandbox/runner"
+import (
+	"log/slog"
+	"syscall"
+
+	"github.com/criyle/go-sandbox/runner"
+)
 
 // TraceAction defines the action returned by TraceHandle
 type TraceAction int
@@ -12,13 +17,51 @@ const (
 	TraceBan
 	// TraceKill referred as dangerous action have been detected
 	TraceKill
+	// TraceExit allows the syscall and stops at its exit to call HandleExit
+	// if the Handler implements ExitHandler
+	TraceExit
+	// TraceRedirect allows the syscall with the arguments modified by SetArg
+	// or SetArgString
+	TraceRedirect
 )
 
+var traceActionString = []string{
+	"allow",
+	"ban",
+	"kill",
+	"exit",
+	"redirect",
+}
+
+func (a TraceAction) String() string {
+	if a >= 0 && int(a) < len(traceActionString) {
+		return traceActionString[a]
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the action as its name
+func (a TraceAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
 // Tracer defines a ptracer instance
 type Tracer struct {
 	Handler
 	Runner
 	runner.Limit
+
+	// Sampler enforces the Limit during the run when set
+	Sampler *runner.Sampler
+
+	// MaxProcesses limits the total number of processes created during the
+	// run while Limit.ProcessLimit limits the live ones, and MaxThreads limits
+	// the number of concurrent threads including processes. They are checked
+	// at fork / vfork / clone events, 0 for unlimited
+	MaxProcesses, MaxThreads uint64
+
+	// Logger receives structured debug logs of the tracer (default: Handler.Debug)
+	Logger *slog.Logger
 }
 
 // Runner represents the process runner
@@ -33,6 +76,23 @@ type Handler interface {
 	// Handle returns action take to the traced program
 	Handle(*Context) TraceAction
 
-	// Debug prints debug information when in debug mode
+	// Debug prints debug information when in debug mode. It receives the
+	// formatted logs of the tracer if Tracer.Logger is not set
 	Debug(v ...interface{})
 }
+
+// SignalHandler is optionally implemented by the Handler to decide the
+// delivery of signals to the traced processes (signal-delivery stop other than
+// SIGTRAP and SIGSTOP). TraceAllow forwards the signal, TraceBan suppresses it
+// and TraceKill kills the program with StatusSignalled and CauseSignal
+type SignalHandler interface {
+	HandleSignal(pid int, sig syscall.Signal) TraceAction
+}
+
+// ExitHandler is optionally implemented by the Handler to inspect the result
+// of the syscalls that Handle returned TraceExit
+type ExitHandler interface {
+	// HandleExit receives the Context at the syscall exit. The return value
+	// rewritten by SetReturnValue is applied unless TraceKill is returned
+	HandleExit(*Context) TraceAction
+}
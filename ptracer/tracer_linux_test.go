@@ -0,0 +1,289 @@
+package ptracer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/criyle/go-sandbox/pkg/forkexec"
+	"github.com/criyle/go-sandbox/pkg/seccomp/libseccomp"
+	"github.com/criyle/go-sandbox/runner"
+	"golang.org/x/sys/unix"
+)
+
+// newTestTracer returns a tracer of args with a seccomp filter that traces the
+// syscalls in trace and allows the others
+func newTestTracer(t *testing.T, h Handler, trace []string, args ...string) *Tracer {
+	t.Helper()
+	b := libseccomp.Builder{
+		Default: libseccomp.ActionAllow,
+		Trace:   trace,
+	}
+	filter, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Tracer{
+		Handler: h,
+		Runner: &forkexec.Runner{
+			Args:    args,
+			Seccomp: filter.SockFprog(),
+			Ptrace:  true,
+		},
+		Limit: runner.Limit{
+			TimeLimit:     time.Second,
+			MemoryLimit:   256 << 20,
+			WallTimeLimit: 5 * time.Second,
+		},
+	}
+}
+
+type allowHandler struct{}
+
+func (allowHandler) Handle(*Context) TraceAction { return TraceAllow }
+
+func (allowHandler) Debug(v ...interface{}) {}
+
+type exitHandler struct {
+	allowHandler
+	path    string
+	rewrite int
+	ret     []int
+}
+
+func (h *exitHandler) Handle(ctx *Context) TraceAction {
+	if ctx.SyscallNo() == unix.SYS_OPENAT && ctx.GetString(uintptr(ctx.Arg1())) == h.path {
+		return TraceExit
+	}
+	return TraceAllow
+}
+
+func (h *exitHandler) HandleExit(ctx *Context) TraceAction {
+	h.ret = append(h.ret, ctx.ReturnValue())
+	if h.rewrite != 0 {
+		ctx.SetReturnValue(h.rewrite)
+	}
+	return TraceAllow
+}
+
+func TestTraceExit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a")
+	if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+
+	tests := []struct {
+		name    string
+		rewrite int
+		status  runner.Status
+	}{
+		{name: "Inspect", status: runner.StatusNormal},
+		{name: "Rewrite", rewrite: -int(unix.EACCES), status: runner.StatusNonzeroExitStatus},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &exitHandler{path: path, rewrite: tc.rewrite}
+			tracer := newTestTracer(t, h, []string{"openat"}, "/bin/cat", path)
+			tracer.Runner.(*forkexec.Runner).Files = []uintptr{null.Fd(), null.Fd(), null.Fd()}
+			r := tracer.Trace(context.Background())
+			if r.Status != tc.status {
+				t.Fatal(r.Status, r.Error, r)
+			}
+			if len(h.ret) != 1 || h.ret[0] < 0 {
+				t.Fatalf("expected one opened fd at syscall exit, got %v", h.ret)
+			}
+		})
+	}
+}
+
+type redirectHandler struct {
+	allowHandler
+	from, to string
+}
+
+func (h *redirectHandler) Handle(ctx *Context) TraceAction {
+	if ctx.SyscallNo() != unix.SYS_OPENAT || ctx.GetString(uintptr(ctx.Arg1())) != h.from {
+		return TraceAllow
+	}
+	if err := ctx.SetArgString(1, h.to); err != nil {
+		return TraceKill
+	}
+	return TraceRedirect
+}
+
+func TestTraceRedirect(t *testing.T) {
+	dir := t.TempDir()
+	to := filepath.Join(dir, "b")
+	if err := os.WriteFile(to, []byte("redirected"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	from := filepath.Join(dir, "a")
+	tracer := newTestTracer(t, &redirectHandler{from: from, to: to}, []string{"openat"}, "/bin/cat", from)
+	tracer.Runner.(*forkexec.Runner).Files = []uintptr{out.Fd(), out.Fd(), out.Fd()}
+	r := tracer.Trace(context.Background())
+	if r.Status != runner.StatusNormal {
+		t.Fatal(r.Status, r.Error, r)
+	}
+	c, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(c) != "redirected" {
+		t.Fatalf("expected redirected content, got %q", c)
+	}
+}
+
+type contextHandler struct {
+	allowHandler
+	t       *testing.T
+	checked int
+}
+
+func (h *contextHandler) Handle(ctx *Context) TraceAction {
+	if !ctx.IsNativeArch() {
+		h.t.Errorf("expected native arch, got %#x", ctx.Arch())
+	}
+	// syscall decoded by PTRACE_GET_SYSCALL_INFO should match the registers
+	if err := ctx.loadRegs(); err != nil {
+		h.t.Error(err)
+		return TraceKill
+	}
+	nr, args := ctx.syscallRegs()
+	if nr != ctx.SyscallNo() || args != ctx.args || ctx.instructionPointer() != ctx.InstructionPointer() {
+		h.t.Errorf("syscall mismatch: regs %d %v, context %d %v", nr, args, ctx.SyscallNo(), ctx.args)
+	}
+	h.checked++
+	return TraceAllow
+}
+
+func TestTrapContext(t *testing.T) {
+	h := &contextHandler{t: t}
+	tracer := newTestTracer(t, h, []string{"openat", "read", "mmap"}, "/bin/true")
+	r := tracer.Trace(context.Background())
+	if r.Status != runner.StatusNormal {
+		t.Fatal(r.Status, r.Error, r)
+	}
+	if h.checked == 0 {
+		t.Fatal("expected traced syscalls")
+	}
+}
+
+func TestProcessLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		status runner.Status
+	}{
+		{name: "Sequential", script: "/bin/true; /bin/true; /bin/true", status: runner.StatusNormal},
+		{name: "Concurrent", script: "/bin/sleep 1 & /bin/sleep 1 & wait", status: runner.StatusProcessLimitExceeded},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracer := newTestTracer(t, allowHandler{}, []string{"openat"}, "/bin/sh", "-c", tc.script)
+			tracer.Limit.ProcessLimit = 2
+			r := tracer.Trace(context.Background())
+			if r.Status != tc.status {
+				t.Fatal(r.Status, r.Error, r)
+			}
+			if tc.status == runner.StatusProcessLimitExceeded && r.Cause != runner.CauseProcessLimit {
+				t.Fatal(r.Cause, r)
+			}
+		})
+	}
+}
+
+func TestMaxProcesses(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxProcesses uint64
+		status       runner.Status
+	}{
+		{name: "Unlimited", status: runner.StatusNormal},
+		{name: "Enough", maxProcesses: 4, status: runner.StatusNormal},
+		{name: "Exceeded", maxProcesses: 2, status: runner.StatusProcessLimitExceeded},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// processes run one after another so that only the total exceeds
+			tracer := newTestTracer(t, allowHandler{}, []string{"openat"}, "/bin/sh", "-c", "/bin/true; /bin/true; /bin/true")
+			tracer.MaxProcesses = tc.maxProcesses
+			r := tracer.Trace(context.Background())
+			if r.Status != tc.status {
+				t.Fatal(r.Status, r.Error, r)
+			}
+			if tc.status == runner.StatusProcessLimitExceeded && r.Cause != runner.CauseProcessLimit {
+				t.Fatal(r.Cause, r)
+			}
+		})
+	}
+}
+
+type signalHandler struct {
+	allowHandler
+	action TraceAction
+}
+
+func (h signalHandler) HandleSignal(pid int, sig syscall.Signal) TraceAction {
+	if sig == syscall.SIGSEGV {
+		return h.action
+	}
+	return TraceAllow
+}
+
+func TestSignalHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     Handler
+		signal      string
+		status      runner.Status
+		cause       runner.Cause
+		childSignal int
+	}{
+		{name: "Record", handler: allowHandler{}, signal: "SEGV", status: runner.StatusNormal, childSignal: int(syscall.SIGSEGV)},
+		{name: "Forward", handler: signalHandler{action: TraceAllow}, signal: "SEGV", status: runner.StatusNormal, childSignal: int(syscall.SIGSEGV)},
+		{name: "Suppress", handler: signalHandler{action: TraceBan}, signal: "SEGV", status: runner.StatusNormal},
+		{name: "Kill", handler: signalHandler{action: TraceKill}, signal: "SEGV", status: runner.StatusSignalled, cause: runner.CauseSignal},
+		{name: "Terminated", handler: allowHandler{}, signal: "TERM", status: runner.StatusNormal},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// the worker is signalled while the main process exits normally
+			tracer := newTestTracer(t, tc.handler, []string{"openat"},
+				"/bin/sh", "-c", `/bin/sh -c 'kill -`+tc.signal+` $$'; exit 0`)
+			r := tracer.Trace(context.Background())
+			if r.Status != tc.status || r.Cause != tc.cause || r.ChildSignal != tc.childSignal {
+				t.Fatal(r.Status, r.Cause, r.ChildSignal, r)
+			}
+		})
+	}
+}
+
+func TestPtraceSeize(t *testing.T) {
+	// the worker should stay group-stopped until the main process continues it
+	tracer := newTestTracer(t, allowHandler{}, []string{"openat"}, "/bin/sh", "-c",
+		`/bin/sh -c 'kill -STOP $$; exit 3' & i=0; `+
+			`until grep -q '^State:.*[tT]' /proc/$!/status; do `+
+			`i=$((i+1)); [ $i -lt 200 ] || exit 1; sleep 0.01; done; `+
+			`kill -CONT $! && wait $!`)
+	fr := tracer.Runner.(*forkexec.Runner)
+	fr.PtraceSeize = true
+	fr.PtraceOptions = PtraceOptions
+	r := tracer.Trace(context.Background())
+	if r.Status != runner.StatusNonzeroExitStatus || r.ExitStatus != 3 {
+		t.Fatal(r.Status, r.ExitStatus, r)
+	}
+}
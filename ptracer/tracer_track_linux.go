@@ -3,7 +3,10 @@ package ptracer
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"runtime"
+	"strconv"
 	"time"
 
 	unix "golang.org/x/sys/unix"
@@ -19,45 +22,28 @@ func (t *Tracer) Trace(c context.Context) (result runner.Result) {
 	defer runtime.UnlockOSThread()
 
 	// Start the runner
+	log := t.logger()
 	pgid, err := t.Runner.Start()
-	t.Handler.Debug("tracer started: ", pgid, err)
 	if err != nil {
-		t.Handler.Debug("start tracee failed: ", err)
+		log.Debug("start tracee failed", "err", err)
 		result.Status = runner.StatusRunnerError
 		result.Error = err.Error()
 		return
 	}
-	return t.trace(c, pgid)
+	log.Debug("tracer started", "pid", pgid)
+	return t.trace(c, log, pgid)
 }
 
-func (t *Tracer) trace(c context.Context, pgid int) (result runner.Result) {
-	cc, cancel := context.WithCancel(c)
-	defer cancel()
-
-	// handle cancellation
-	go func() {
-		<-cc.Done()
-		killAll(pgid)
-	}()
-
-	sTime := time.Now()
-	ph := newPtraceHandle(t, pgid)
+func (t *Tracer) trace(c context.Context, log *slog.Logger, pgid int) (result runner.Result) {
+	tr := t.newTracing(c, log, pgid)
 
 	// handler potential panic and tle
 	// also ensure processes was well terminated
 	defer func() {
 		if err := recover(); err != nil {
-			t.Handler.Debug("panic: ", err)
-			result.Status = runner.StatusRunnerError
-			result.Error = fmt.Sprintf("%v", err)
-		}
-		// kill all tracee upon return
-		killAll(pgid)
-		collectZombie(pgid)
-		if !ph.fTime.IsZero() {
-			result.SetUpTime = ph.fTime.Sub(sTime)
-			result.RunningTime = time.Since(ph.fTime)
+			tr.panicked(err)
 		}
+		result = tr.finish()
 	}()
 
 	// ptrace pool loop
@@ -68,7 +54,7 @@ func (t *Tracer) trace(c context.Context, pgid int) (result runner.Result) {
 			pid     int             // store pid of wait4 result
 			err     error
 		)
-		if ph.execved {
+		if tr.execved {
 			// Wait for all child in the process group
 			pid, err = unix.Wait4(-pgid, &wstatus, unix.WALL, &rusage)
 		} else {
@@ -76,36 +62,104 @@ func (t *Tracer) trace(c context.Context, pgid int) (result runner.Result) {
 			pid, err = unix.Wait4(pgid, &wstatus, unix.WALL, &rusage)
 		}
 		if err == unix.EINTR {
-			t.Handler.Debug("wait4 EINTR")
 			continue
 		}
 		if err != nil {
-			t.Handler.Debug("wait4 failed: ", err)
-			result.Status = runner.StatusRunnerError
-			result.Error = err.Error()
+			log.Debug("wait4 failed", "pid", pgid, "err", err)
+			tr.result.Status = runner.StatusRunnerError
+			tr.result.Error = err.Error()
 			return
 		}
-		t.Handler.Debug("------ ", pid, " ------")
-
-		// update rusage
-		if pid == pgid {
-			userTime, userMem, curStatus := t.checkUsage(rusage)
-			result.Status = curStatus
-			result.Time = userTime
-			result.Memory = userMem
-			if curStatus != runner.StatusNormal {
-				return
-			}
+		if tr.event(pid, wstatus, rusage) {
+			return
 		}
+	}
+}
 
-		status, exitStatus, errStr, finished := ph.handle(pid, wstatus)
-		if finished || status != runner.StatusNormal {
-			result.Status = status
-			result.ExitStatus = exitStatus
-			result.Error = errStr
-			return
+// tracing is the state of a single traced run, shared by Tracer.Trace and
+// the Scheduler
+type tracing struct {
+	*ptraceHandle
+	ctx      context.Context
+	cancel   context.CancelFunc
+	sTime    time.Time
+	sampling *runner.Sampling
+	result   runner.Result
+}
+
+func (t *Tracer) newTracing(c context.Context, log *slog.Logger, pgid int) *tracing {
+	cc, cancel := t.Limit.WithWallTimeLimit(c)
+
+	// handle cancellation
+	go func() {
+		<-cc.Done()
+		killAll(pgid)
+	}()
+
+	tr := &tracing{
+		ptraceHandle: newPtraceHandle(t, log, pgid),
+		ctx:          cc,
+		cancel:       cancel,
+		sTime:        time.Now(),
+	}
+	if t.Sampler != nil {
+		tr.sampling = t.Sampler.Start(pgid, t.Limit, func() { killAll(pgid) })
+	}
+	return tr
+}
+
+// event handles a wait4 result of a tracee and returns whether the run has
+// finished
+func (tr *tracing) event(pid int, wstatus unix.WaitStatus, rusage unix.Rusage) bool {
+	// update rusage
+	if pid == tr.pgid {
+		userTime, userMem, curStatus := tr.checkUsage(rusage)
+		tr.result.Status = curStatus
+		tr.result.Cause = runner.LimitCause(curStatus)
+		tr.result.Time = userTime
+		tr.result.Memory = userMem
+		if curStatus != runner.StatusNormal {
+			return true
 		}
 	}
+
+	status, cause, exitStatus, errStr, finished := tr.handle(pid, wstatus)
+	if finished || status != runner.StatusNormal {
+		tr.result.Status = status
+		tr.result.Cause = cause
+		tr.result.ExitStatus = exitStatus
+		tr.result.Error = errStr
+		return true
+	}
+	return false
+}
+
+func (tr *tracing) panicked(err any) {
+	tr.log.Error("tracer panic", "pid", tr.pgid, "err", err)
+	tr.result.Status = runner.StatusRunnerError
+	tr.result.Error = fmt.Sprintf("%v", err)
+}
+
+// finish kills and collects all tracee and completes the result
+func (tr *tracing) finish() runner.Result {
+	defer tr.cancel()
+
+	// kill all tracee upon return
+	killAll(tr.pgid)
+	collectZombie(tr.pgid)
+	result := tr.result
+	if tr.sampling != nil {
+		tr.sampling.Finish(&result)
+	}
+	tr.Limit.CheckContext(tr.ctx, &result)
+	result.ChildSignal = int(tr.childSignal)
+	if !tr.fTime.IsZero() {
+		result.SetUpTime = tr.fTime.Sub(tr.sTime)
+		result.RunningTime = time.Since(tr.fTime)
+	}
+	tr.log.Debug("tracer finished", "pid", tr.pgid, "status", result.Status, "cause", result.Cause,
+		"elapsed", time.Since(tr.sTime))
+	return result
 }
 
 func (t *Tracer) checkUsage(rusage unix.Rusage) (time.Duration, runner.Size, runner.Status) {
@@ -126,23 +180,44 @@ func (t *Tracer) checkUsage(rusage unix.Rusage) (time.Duration, runner.Size, run
 
 type ptraceHandle struct {
 	*Tracer
+	log     *slog.Logger
 	pgid    int
 	traced  map[int]bool
+	exiting map[int]*Context // entry context of processes waiting for the syscall exit stop
 	execved bool
 	fTime   time.Time
+
+	tasks     map[int]bool // live tasks counted at fork / clone events, true for processes
+	processes uint64       // live processes in tasks
+	created   uint64       // total processes created during the run
+
+	childSignal unix.Signal // first fatal signal that terminated a child process
+
+	newTask func(pid int) // called with the task created at fork / clone events (optional)
 }
 
-func newPtraceHandle(t *Tracer, pgid int) *ptraceHandle {
-	return &ptraceHandle{t, pgid, make(map[int]bool), false, time.Time{}}
+func newPtraceHandle(t *Tracer, log *slog.Logger, pgid int) *ptraceHandle {
+	return &ptraceHandle{
+		Tracer:    t,
+		log:       log,
+		pgid:      pgid,
+		traced:    make(map[int]bool),
+		exiting:   make(map[int]*Context),
+		tasks:     map[int]bool{pgid: true},
+		processes: 1,
+		created:   1,
+	}
 }
 
-func (ph *ptraceHandle) handle(pid int, wstatus unix.WaitStatus) (status runner.Status, exitStatus int, errStr string, finished bool) {
+func (ph *ptraceHandle) handle(pid int, wstatus unix.WaitStatus) (status runner.Status, cause runner.Cause, exitStatus int, errStr string, finished bool) {
 	status = runner.StatusNormal
 	// check process status
 	switch {
 	case wstatus.Exited():
 		delete(ph.traced, pid)
-		ph.Handler.Debug("process exited: ", pid, wstatus.ExitStatus())
+		delete(ph.exiting, pid)
+		ph.removeTask(pid)
+		ph.log.Debug("process exited", "pid", pid, "exit", wstatus.ExitStatus())
 		if pid == ph.pgid {
 			finished = true
 			if ph.execved {
@@ -161,28 +236,24 @@ func (ph *ptraceHandle) handle(pid int, wstatus unix.WaitStatus) (status runner.
 
 	case wstatus.Signaled():
 		sig := wstatus.Signal()
-		ph.Handler.Debug("ptrace signaled: ", sig)
+		ph.log.Debug("process signaled", "pid", pid, "signal", sig)
+		delete(ph.traced, pid)
+		delete(ph.exiting, pid)
+		ph.removeTask(pid)
 		if pid == ph.pgid {
-			delete(ph.traced, pid)
-			switch sig {
-			case unix.SIGXCPU, unix.SIGKILL:
-				status = runner.StatusTimeLimitExceeded
-			case unix.SIGXFSZ:
-				status = runner.StatusOutputLimitExceeded
-			case unix.SIGSYS:
-				status = runner.StatusDisallowedSyscall
-			default:
-				status = runner.StatusSignalled
-			}
+			status, cause = runner.SignalStatus(sig)
 			exitStatus = int(sig)
 			return
 		}
+		if ph.childSignal == 0 && isFatalSignal(sig) {
+			ph.childSignal = sig
+		}
 		unix.PtraceCont(pid, int(sig))
 
 	case wstatus.Stopped():
 		// Set option if the process is newly forked
 		if !ph.traced[pid] {
-			ph.Handler.Debug("set ptrace option for", pid)
+			ph.log.Debug("set ptrace option", "pid", pid)
 			ph.traced[pid] = true
 			// Ptrace set option valid if the tracee is stopped
 			if err := setPtraceOption(pid); err != nil {
@@ -193,6 +264,15 @@ func (ph *ptraceHandle) handle(pid int, wstatus unix.WaitStatus) (status runner.
 		}
 
 		stopSig := wstatus.StopSignal()
+		// group-stop of the seized tracee (PTRACE_EVENT_STOP with the stop
+		// signal), listen to keep it stopped until SIGCONT
+		if stopSig != unix.SIGTRAP && int(wstatus>>16) == unix.PTRACE_EVENT_STOP {
+			ph.log.Debug("ptrace group stop", "pid", pid, "signal", stopSig)
+			if err := ptraceListen(pid); err != nil {
+				ph.log.Debug("ptrace listen failed", "pid", pid, "err", err)
+			}
+			return
+		}
 		// Check stop signal, if trap then check seccomp
 		switch stopSig {
 		case unix.SIGTRAP:
@@ -200,41 +280,60 @@ func (ph *ptraceHandle) handle(pid int, wstatus unix.WaitStatus) (status runner.
 			case unix.PTRACE_EVENT_SECCOMP:
 				if ph.execved {
 					// give the customized handle for syscall
-					err := ph.handleTrap(pid)
+					entry, err := ph.handleTrap(pid)
 					if err != nil {
 						status = runner.StatusDisallowedSyscall
 						errStr = err.Error()
 						return
 					}
+					if entry != nil {
+						ph.exiting[pid] = entry
+					}
 				} else {
-					ph.Handler.Debug("ptrace seccomp before execve (should be the execve syscall)")
+					ph.log.Debug("seccomp stop before execve (should be the execve syscall)", "pid", pid)
 				}
 
-			case unix.PTRACE_EVENT_CLONE:
-				ph.Handler.Debug("ptrace stop clone")
-			case unix.PTRACE_EVENT_VFORK:
-				ph.Handler.Debug("ptrace stop vfork")
-			case unix.PTRACE_EVENT_FORK:
-				ph.Handler.Debug("ptrace stop fork")
+			case unix.PTRACE_EVENT_CLONE, unix.PTRACE_EVENT_VFORK, unix.PTRACE_EVENT_FORK:
+				if !ph.checkNewTask(pid, trapCause) {
+					status = runner.StatusProcessLimitExceeded
+					cause = runner.CauseProcessLimit
+					return
+				}
 			case unix.PTRACE_EVENT_EXEC:
 				// forked tracee have successfully called execve
 				if !ph.execved {
 					ph.fTime = time.Now()
 					ph.execved = true
 				}
-				ph.Handler.Debug("ptrace stop exec")
+				ph.log.Debug("ptrace stop exec", "pid", pid)
+
+			case unix.PTRACE_EVENT_STOP:
+				// initial stop of new tracee, end of group-stop or
+				// PTRACE_INTERRUPT of the seized tracee
+				ph.log.Debug("ptrace event stop", "pid", pid)
 
 			default:
-				ph.Handler.Debug("ptrace unexpected trap cause: ", trapCause)
+				ph.log.Debug("ptrace unexpected trap cause", "pid", pid, "cause", trapCause)
+			}
+			ph.cont(pid, 0)
+			return
+
+		// syscall stop (PTRACE_O_TRACESYSGOOD) requested by TraceExit
+		case unix.SIGTRAP | 0x80:
+			if entry := ph.exiting[pid]; entry != nil {
+				delete(ph.exiting, pid)
+				if err := ph.handleExit(pid, entry); err != nil {
+					status = runner.StatusDisallowedSyscall
+					errStr = err.Error()
+					return
+				}
 			}
-			unix.PtraceCont(pid, 0)
+			ph.cont(pid, 0)
 			return
 
 		// check if cpu rlimit hit
-		case unix.SIGXCPU:
-			status = runner.StatusTimeLimitExceeded
-		case unix.SIGXFSZ:
-			status = runner.StatusOutputLimitExceeded
+		case unix.SIGXCPU, unix.SIGXFSZ:
+			status, cause = runner.SignalStatus(stopSig)
 		}
 		if status != runner.StatusNormal {
 			return
@@ -242,47 +341,170 @@ func (ph *ptraceHandle) handle(pid int, wstatus unix.WaitStatus) (status runner.
 		// Likely encountered SIGSEGV (segment violation)
 		// Or compiler child exited
 		if stopSig != unix.SIGSTOP {
-			ph.Handler.Debug("ptrace unexpected stop signal: ", stopSig)
+			ph.log.Debug("ptrace unexpected stop signal", "pid", pid, "signal", stopSig)
+			if h, ok := ph.Handler.(SignalHandler); ok {
+				act := h.HandleSignal(pid, stopSig)
+				ph.log.Debug("signal handled", "pid", pid, "signal", stopSig, "action", act)
+				switch act {
+				case TraceBan:
+					ph.cont(pid, 0)
+					return
+				case TraceKill:
+					status = runner.StatusSignalled
+					cause = runner.CauseSignal
+					exitStatus = int(stopSig)
+					return
+				}
+			}
 		}
-		ph.Handler.Debug("ptrace stopped")
-		unix.PtraceCont(pid, int(stopSig))
+		ph.cont(pid, int(stopSig))
 	}
 	return
 }
 
-// handleTrap handles the seccomp trap including the custom handle
-func (ph *ptraceHandle) handleTrap(pid int) error {
-	ph.Handler.Debug("seccomp traced")
-	// msg, err := unix.PtraceGetEventMsg(pid)
-	// if err != nil {
-	// 	t.Handler.Debug("PtraceGetEventMsg failed:", err)
-	// 	return err
-	// }
+// checkNewTask counts the task created by fork / vfork / clone and checks
+// it against Limit.ProcessLimit, MaxProcesses and MaxThreads
+func (ph *ptraceHandle) checkNewTask(pid int, trapCause int) bool {
+	msg, err := unix.PtraceGetEventMsg(pid)
+	if err != nil {
+		ph.log.Debug("ptrace get event message failed", "pid", pid, "err", err)
+		return true
+	}
+	child := int(msg)
+	if ph.newTask != nil {
+		ph.newTask(child)
+	}
+	// clone event is also reported for processes created without SIGCHLD
+	thread := trapCause == unix.PTRACE_EVENT_CLONE && isThread(pid, child)
+	if _, ok := ph.tasks[child]; !ok {
+		ph.tasks[child] = !thread
+		if !thread {
+			ph.processes++
+			ph.created++
+		}
+	}
+	ph.log.Debug("ptrace stop new task", "pid", pid, "child", child, "event", trapCause,
+		"thread", thread, "processes", ph.processes, "created", ph.created, "threads", len(ph.tasks))
+
+	if ph.Limit.ProcessLimit > 0 && ph.processes > ph.Limit.ProcessLimit {
+		ph.log.Debug("process limit exceeded", "pid", pid, "processes", ph.processes)
+		return false
+	}
+	if ph.MaxProcesses > 0 && ph.created > ph.MaxProcesses {
+		ph.log.Debug("max processes exceeded", "pid", pid, "created", ph.created)
+		return false
+	}
+	if ph.MaxThreads > 0 && uint64(len(ph.tasks)) > ph.MaxThreads {
+		ph.log.Debug("max threads exceeded", "pid", pid, "threads", len(ph.tasks))
+		return false
+	}
+	return true
+}
+
+// isFatalSignal returns whether the signal terminates the program by a fault
+// (signals with core dump as the default action), e.g. SIGSEGV or SIGFPE,
+// rather than a kill from the tracer or another process
+func isFatalSignal(sig unix.Signal) bool {
+	switch sig {
+	case unix.SIGSEGV, unix.SIGFPE, unix.SIGBUS, unix.SIGILL, unix.SIGABRT,
+		unix.SIGTRAP, unix.SIGSYS, unix.SIGQUIT, unix.SIGXCPU, unix.SIGXFSZ:
+		return true
+	}
+	return false
+}
+
+// removeTask removes the exited task from the live tasks
+func (ph *ptraceHandle) removeTask(pid int) {
+	if ph.tasks[pid] {
+		ph.processes--
+	}
+	delete(ph.tasks, pid)
+}
+
+// isThread returns whether child is in the same thread group as pid
+func isThread(pid, child int) bool {
+	_, err := os.Stat("/proc/" + strconv.Itoa(pid) + "/task/" + strconv.Itoa(child))
+	return err == nil
+}
+
+// handleTrap handles the seccomp trap including the custom handle, it returns
+// the context if the syscall exit should be stopped
+func (ph *ptraceHandle) handleTrap(pid int) (*Context, error) {
 	if ph.Handler != nil {
 		ctx, err := getTrapContext(pid)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		act := ph.Handler.Handle(ctx)
+		ph.log.Debug("seccomp traced", "pid", pid, "syscall", ctx.SyscallNo(), "action", act)
 
 		switch act {
 		case TraceBan:
 			// Set the syscallno to -1 and return value into register to skip syscall.
 			// https://www.kernel.org/doc/Documentation/prctl/pkg/seccomp_filter.txt
-			return ctx.skipSyscall()
+			if err := ctx.loadRegs(); err != nil {
+				return nil, err
+			}
+			return nil, ctx.skipSyscall()
 
 		case TraceKill:
-			return runner.StatusDisallowedSyscall
+			return nil, runner.StatusDisallowedSyscall
+
+		case TraceExit:
+			if _, ok := ph.Handler.(ExitHandler); ok {
+				return ctx, nil
+			}
+
+		case TraceRedirect:
+			return nil, ctx.setRegs()
 		}
 	}
+	return nil, nil
+}
+
+// handleExit handles the syscall exit stop by the ExitHandler and writes back
+// the rewritten return value
+func (ph *ptraceHandle) handleExit(pid int, entry *Context) error {
+	h, ok := ph.Handler.(ExitHandler)
+	if !ok {
+		return nil
+	}
+	ctx, err := getExitContext(pid, entry)
+	if err != nil {
+		return err
+	}
+	ret := ctx.ReturnValue()
+	act := h.HandleExit(ctx)
+	ph.log.Debug("syscall exit", "pid", pid, "syscall", ctx.SyscallNo(), "return", ret,
+		"rewrite", ctx.ReturnValue(), "action", act)
+
+	if act == TraceKill {
+		return runner.StatusDisallowedSyscall
+	}
+	if ctx.ReturnValue() != ret {
+		return ctx.setRegs()
+	}
 	return nil
 }
 
+// cont resumes the stopped tracee, PTRACE_SYSCALL is used if the tracee is
+// waiting for the syscall exit stop
+func (ph *ptraceHandle) cont(pid, sig int) {
+	if ph.exiting[pid] != nil {
+		unix.PtraceSyscall(pid, sig)
+		return
+	}
+	unix.PtraceCont(pid, sig)
+}
+
+// PtraceOptions set up seccomp, exit kill and all mult-process actions. A
+// Runner attaching its child by PTRACE_SEIZE should attach with these options
+const PtraceOptions = unix.PTRACE_O_TRACESECCOMP | unix.PTRACE_O_EXITKILL | unix.PTRACE_O_TRACEFORK |
+	unix.PTRACE_O_TRACECLONE | unix.PTRACE_O_TRACEEXEC | unix.PTRACE_O_TRACEVFORK | unix.PTRACE_O_TRACESYSGOOD
+
 // set Ptrace option that set up seccomp, exit kill and all mult-process actions
 func setPtraceOption(pid int) error {
-	const ptraceFlags = unix.PTRACE_O_TRACESECCOMP | unix.PTRACE_O_EXITKILL | unix.PTRACE_O_TRACEFORK |
-		unix.PTRACE_O_TRACECLONE | unix.PTRACE_O_TRACEEXEC | unix.PTRACE_O_TRACEVFORK
-	return unix.PtraceSetOptions(pid, ptraceFlags)
+	return unix.PtraceSetOptions(pid, PtraceOptions)
 }
 
 // kill all tracee according to pids
@@ -0,0 +1,69 @@
+package runner
+
+// Cause is what terminated the program, which tells apart results sharing
+// the same Status (e.g. SIGKILL is reported as StatusTimeLimitExceeded)
+type Cause int
+
+// Terminating causes for program runner
+const (
+	CauseNone Cause = iota // 0 exited by itself or signalled by itself
+
+	// Resource Limit Exceeded
+	CauseCPUTimeLimit  // 1 cpu time limit (rlimit or runner check)
+	CauseWallTimeLimit // 2 wall time limit
+	CauseMemoryLimit   // 3 memory limit (runner check)
+	CauseOOM           // 4 killed by oom killer
+	CauseOutputLimit   // 5 output size limit (rlimit)
+	CauseProcessLimit  // 6 process count limit
+
+	// Killed by caller
+	CauseCancelled // 7 context cancelled by caller
+
+	// Unknown
+	CauseKilled // 8 killed by SIGKILL from unknown source
+
+	// Killed by runner policy
+	CauseSignal // 9 killed by the tracer on a signal received by the program
+)
+
+var (
+	causeString = []string{
+		"",
+		"CPU Time Limit",
+		"Wall Time Limit",
+		"Memory Limit",
+		"Out Of Memory",
+		"Output Limit",
+		"Process Limit",
+		"Cancelled",
+		"Killed",
+		"Signal",
+	}
+)
+
+func (c Cause) String() string {
+	i := int(c)
+	if i >= 0 && i < len(causeString) {
+		return causeString[i]
+	}
+	return causeString[0]
+}
+
+// LimitCause returns the cause of the status set by a runner after it checked
+// the usage against the limit
+func LimitCause(s Status) Cause {
+	switch s {
+	case StatusTimeLimitExceeded:
+		return CauseCPUTimeLimit
+	case StatusRealTimeLimitExceeded:
+		return CauseWallTimeLimit
+	case StatusMemoryLimitExceeded:
+		return CauseMemoryLimit
+	case StatusOutputLimitExceeded:
+		return CauseOutputLimit
+	case StatusProcessLimitExceeded:
+		return CauseProcessLimit
+	default:
+		return CauseNone
+	}
+}
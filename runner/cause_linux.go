@@ -0,0 +1,21 @@
+package runner
+
+import "syscall"
+
+// SignalStatus returns the status and the terminating cause of the program
+// terminated by sig
+func SignalStatus(sig syscall.Signal) (Status, Cause) {
+	switch sig {
+	case syscall.SIGXCPU:
+		return StatusTimeLimitExceeded, CauseCPUTimeLimit
+	case syscall.SIGKILL:
+		// kill signal treats as TLE
+		return StatusTimeLimitExceeded, CauseKilled
+	case syscall.SIGXFSZ:
+		return StatusOutputLimitExceeded, CauseOutputLimit
+	case syscall.SIGSYS:
+		return StatusDisallowedSyscall, CauseNone
+	default:
+		return StatusSignalled, CauseNone
+	}
+}
@@ -7,7 +7,7 @@
 //
 //	Normal
 //	Program Error
-//	    Resource Limit Exceeded (Time / Memory / Output)
+//	    Resource Limit Exceeded (Time / Real Time / Memory / Output / Process)
 //	    Unauthorized Access (Disallowed Syscall)
 //	    Runtime Error (Signaled / Nonzero Exit Status)
 //	Program Runner Error
@@ -19,13 +19,26 @@
 //
 // # Limit
 //
-// Limit defines Time & Memory restriction on program runner
+// Limit defines CPU Time, Memory, Wall Time, Output & Process restriction on
+// program runner
+//
+// # Sampler
+//
+// Sampler polls the resource usage during the run to enforce Limit while
+// the program is blocked and records a compact time series of the usage
 //
 // # Result
 //
 // Result defines program running result including
 // Status, ExitStatus, Detailed Error, Time, Memory,
-// SetupTime and RunningTime (in real clock)
+// SetupTime and RunningTime (in real clock), the cgroup cpu throttling and
+// pressure stall, and the policy Violations recorded by the ptrace runner in
+// audit mode
+//
+// # Cause
+//
+// Cause records what actually terminated the program, e.g. CPU time, wall
+// time or OOM kill, since several of them map to the same Status
 //
 // # Runner
 //
@@ -1,16 +1,59 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 )
 
 // Limit represents the resource limit for traced process
 type Limit struct {
-	TimeLimit   time.Duration // user CPU time limit (in ns)
-	MemoryLimit Size          // user memory limit (in bytes)
+	TimeLimit     time.Duration // user CPU time limit (in ns)
+	MemoryLimit   Size          // user memory limit (in bytes)
+	WallTimeLimit time.Duration // real (wall clock) time limit (in ns), 0 for unlimited
+	OutputLimit   Size          // output file size limit (in bytes), 0 for unlimited
+	ProcessLimit  uint64        // maximum number of live processes (ptrace runner only, others reject it), 0 for unlimited
+}
+
+// errWallTimeLimit is the cause of the context cancelled by WallTimeLimit
+var errWallTimeLimit = errors.New("wall time limit exceeded")
+
+// WithWallTimeLimit returns a context that is cancelled once the WallTimeLimit
+// is reached. The context is returned as-is if WallTimeLimit is not set
+func (l Limit) WithWallTimeLimit(c context.Context) (context.Context, context.CancelFunc) {
+	if l.WallTimeLimit <= 0 {
+		return context.WithCancel(c)
+	}
+	return context.WithTimeoutCause(c, l.WallTimeLimit, errWallTimeLimit)
+}
+
+// WallTimeLimitExceeded returns true if the context returned by
+// WithWallTimeLimit was cancelled by the WallTimeLimit
+func WallTimeLimitExceeded(c context.Context) bool {
+	return context.Cause(c) == errWallTimeLimit
+}
+
+// CheckContext sets the cause of the program killed after the context
+// returned by WithWallTimeLimit was done. The kill after WallTimeLimit is
+// converted into StatusRealTimeLimitExceeded unless the CPU time also exceeds
+// the TimeLimit, otherwise the context was cancelled by the caller
+func (l Limit) CheckContext(c context.Context, r *Result) {
+	if r.Cause != CauseKilled || c.Err() == nil {
+		return
+	}
+	switch {
+	case !WallTimeLimitExceeded(c):
+		r.Cause = CauseCancelled
+	case l.TimeLimit > 0 && r.Time > l.TimeLimit:
+		r.Cause = CauseCPUTimeLimit
+	default:
+		r.Status = StatusRealTimeLimitExceeded
+		r.Cause = CauseWallTimeLimit
+	}
 }
 
 func (l Limit) String() string {
-	return fmt.Sprintf("Limit[Time=%v, Memory=%v]", l.TimeLimit, l.MemoryLimit)
+	return fmt.Sprintf("Limit[Time=%v, Memory=%v, WallTime=%v, Output=%v, Process=%d]",
+		l.TimeLimit, l.MemoryLimit, l.WallTimeLimit, l.OutputLimit, l.ProcessLimit)
 }
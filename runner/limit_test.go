@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimitCheckContext(t *testing.T) {
+	l := Limit{TimeLimit: time.Second, WallTimeLimit: time.Millisecond}
+	wall, cancel := l.WithWallTimeLimit(context.Background())
+	defer cancel()
+	<-wall.Done()
+
+	cancelled, cancel := l.WithWallTimeLimit(context.Background())
+	cancel()
+
+	killed := Result{Status: StatusTimeLimitExceeded, Cause: CauseKilled, Time: 10 * time.Millisecond}
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		r      Result
+		status Status
+		cause  Cause
+	}{
+		{"wall time", wall, killed, StatusRealTimeLimitExceeded, CauseWallTimeLimit},
+		{"cpu time", wall, Result{Status: StatusTimeLimitExceeded, Cause: CauseKilled, Time: 2 * time.Second}, StatusTimeLimitExceeded, CauseCPUTimeLimit},
+		{"cancelled", cancelled, killed, StatusTimeLimitExceeded, CauseCancelled},
+		{"running", context.Background(), killed, StatusTimeLimitExceeded, CauseKilled},
+		{"exited", wall, Result{Status: StatusNormal}, StatusNormal, CauseNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.r
+			l.CheckContext(tt.ctx, &r)
+			if r.Status != tt.status || r.Cause != tt.cause {
+				t.Errorf("expected %v(%v), got %v(%v)", tt.status, tt.cause, r.Status, r.Cause)
+			}
+		})
+	}
+}
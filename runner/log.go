@@ -0,0 +1,19 @@
+package runner
+
+import (
+	"log/slog"
+	"os"
+)
+
+// Logger returns l if it is not nil. Otherwise it returns a logger that
+// writes debug logs to stderr if showDetails is set or discards all logs
+func Logger(l *slog.Logger, showDetails bool) *slog.Logger {
+	switch {
+	case l != nil:
+		return l
+	case showDetails:
+		return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	default:
+		return slog.New(slog.DiscardHandler)
+	}
+}
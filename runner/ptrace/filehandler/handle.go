@@ -5,7 +5,10 @@ import (
 )
 
 // Handler defines file access restricted handler to call the ptrace
-// safe runner
+// safe runner. It always returns the enforcing action, and the audit mode of
+// the runner (ptrace.Runner.Audit) records that action as the violation and
+// relaxes it, so that the same Handler dry-runs a FileSets policy without a
+// separate option here
 type Handler struct {
 	FileSet        *FileSets
 	SyscallCounter SyscallCounter
@@ -1,30 +1,75 @@
 package ptrace
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/criyle/go-sandbox/pkg/seccomp/libseccomp"
 	"github.com/criyle/go-sandbox/ptracer"
+	"github.com/criyle/go-sandbox/runner"
 )
 
 type tracerHandler struct {
 	ShowDetails, Unsafe bool
 	Handler             Handler
+	Logger              *slog.Logger
+	Recorder            Recorder
+	Audit, AuditBan     bool
+
+	// paths and decoded arguments of the current syscall for the Recorder
+	paths []string
+	attrs map[string]string
+
+	// violations recorded in audit mode, indexed by syscall, path and action
+	violations     []runner.Violation
+	violationIndex map[violationKey]int
+}
+
+// violationKey identifies the recorded violation
+type violationKey struct {
+	syscall, path string
+	action        ptracer.TraceAction
 }
 
 const atFDCWD = -100
 const maxSymlinkDepth = 40
 
 func (h *tracerHandler) Debug(v ...interface{}) {
-	if h.ShowDetails {
-		fmt.Fprintln(os.Stderr, v...)
+	h.logger().Debug(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func (h *tracerHandler) logger() *slog.Logger {
+	if h.Logger == nil {
+		h.Logger = runner.Logger(nil, h.ShowDetails)
 	}
+	return h.Logger
+}
+
+// logPath logs the path accessed by the traced syscall and keeps it for the
+// recorded event
+func (h *tracerHandler) logPath(ctx *ptracer.Context, msg, path string, args ...any) {
+	if h.Recorder != nil {
+		h.paths = append(h.paths, path)
+		for i := 0; i+1 < len(args); i += 2 {
+			if h.attrs == nil {
+				h.attrs = make(map[string]string)
+			}
+			h.attrs[fmt.Sprint(args[i])] = fmt.Sprint(args[i+1])
+		}
+	}
+	l := h.logger()
+	if !l.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	l.Debug(msg, append([]any{"pid", ctx.Pid, "path", path}, args...)...)
 }
 
 func (h *tracerHandler) getString(ctx *ptracer.Context, addr uint) string {
@@ -38,12 +83,12 @@ func (h *tracerHandler) getStringAt(ctx *ptracer.Context, dirfd int, addr uint)
 func (h *tracerHandler) checkOpen(ctx *ptracer.Context, addr uint, flags uint) ptracer.TraceAction {
 	fn := h.getString(ctx, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("open proc policy: ", fn, getFileMode(flags))
+		h.logPath(ctx, "open proc policy", fn, "mode", getFileMode(flags))
 		return action
 	}
 	isReadOnly := isOpenReadOnly(uint64(flags))
 
-	h.Debug("open: ", fn, getFileMode(flags))
+	h.logPath(ctx, "open", fn, "mode", getFileMode(flags))
 	if isReadOnly {
 		return h.Handler.CheckRead(fn)
 	}
@@ -53,12 +98,12 @@ func (h *tracerHandler) checkOpen(ctx *ptracer.Context, addr uint, flags uint) p
 func (h *tracerHandler) checkOpenAt(ctx *ptracer.Context, dirfd int, addr uint, flags uint) ptracer.TraceAction {
 	fn := h.getStringAt(ctx, dirfd, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("openat proc policy: ", fn, getFileMode(flags), "dirfd:", dirfd)
+		h.logPath(ctx, "openat proc policy", fn, "mode", getFileMode(flags), "dirfd", dirfd)
 		return action
 	}
 	isReadOnly := isOpenReadOnly(uint64(flags))
 
-	h.Debug("openat: ", fn, getFileMode(flags), "dirfd:", dirfd)
+	h.logPath(ctx, "openat", fn, "mode", getFileMode(flags), "dirfd", dirfd)
 	if isReadOnly {
 		return h.Handler.CheckRead(fn)
 	}
@@ -68,7 +113,7 @@ func (h *tracerHandler) checkOpenAt(ctx *ptracer.Context, dirfd int, addr uint,
 func (h *tracerHandler) checkOpenAt2(ctx *ptracer.Context, dirfd int, addr uint, howAddr uint) ptracer.TraceAction {
 	fn := h.getStringAt(ctx, dirfd, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("openat2 proc policy: ", fn, "dirfd:", dirfd)
+		h.logPath(ctx, "openat2 proc policy", fn, "dirfd", dirfd)
 		return action
 	}
 
@@ -77,11 +122,11 @@ func (h *tracerHandler) checkOpenAt2(ctx *ptracer.Context, dirfd int, addr uint,
 		// Fail closed for policy classification: if the kernel will attempt an
 		// openat2 but we cannot decode open_how.flags, treat it as a write-capable
 		// open so it cannot bypass writable-path restrictions by looking read-only.
-		h.Debug("openat2: failed to read open_how.flags for ", fn, "dirfd:", dirfd, " err:", err)
+		h.logPath(ctx, "openat2: failed to read open_how.flags", fn, "dirfd", dirfd, "err", err)
 		return h.Handler.CheckWrite(fn)
 	}
 
-	h.Debug("openat2: ", fn, getFileMode(uint(flags)), "dirfd:", dirfd)
+	h.logPath(ctx, "openat2", fn, "mode", getFileMode(uint(flags)), "dirfd", dirfd)
 	if isOpenReadOnly(flags) {
 		return h.Handler.CheckRead(fn)
 	}
@@ -91,69 +136,77 @@ func (h *tracerHandler) checkOpenAt2(ctx *ptracer.Context, dirfd int, addr uint,
 func (h *tracerHandler) checkRead(ctx *ptracer.Context, addr uint) ptracer.TraceAction {
 	fn := h.getString(ctx, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("check read proc policy: ", fn)
+		h.logPath(ctx, "check read proc policy", fn)
 		return action
 	}
-	h.Debug("check read: ", fn)
+	h.logPath(ctx, "check read", fn)
 	return h.Handler.CheckRead(fn)
 }
 
 func (h *tracerHandler) checkReadAt(ctx *ptracer.Context, dirfd int, addr uint) ptracer.TraceAction {
 	fn := h.getStringAt(ctx, dirfd, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("check read proc policy: ", fn, "dirfd:", dirfd)
+		h.logPath(ctx, "check read proc policy", fn, "dirfd", dirfd)
 		return action
 	}
-	h.Debug("check read: ", fn, "dirfd:", dirfd)
+	h.logPath(ctx, "check read", fn, "dirfd", dirfd)
 	return h.Handler.CheckRead(fn)
 }
 
 func (h *tracerHandler) checkWrite(ctx *ptracer.Context, addr uint) ptracer.TraceAction {
 	fn := h.getString(ctx, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("check write proc policy: ", fn)
+		h.logPath(ctx, "check write proc policy", fn)
 		return action
 	}
-	h.Debug("check write: ", fn)
+	h.logPath(ctx, "check write", fn)
 	return h.Handler.CheckWrite(fn)
 }
 
 func (h *tracerHandler) checkWriteAt(ctx *ptracer.Context, dirfd int, addr uint) ptracer.TraceAction {
 	fn := h.getStringAt(ctx, dirfd, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("check write proc policy: ", fn, "dirfd:", dirfd)
+		h.logPath(ctx, "check write proc policy", fn, "dirfd", dirfd)
 		return action
 	}
-	h.Debug("check write: ", fn, "dirfd:", dirfd)
+	h.logPath(ctx, "check write", fn, "dirfd", dirfd)
 	return h.Handler.CheckWrite(fn)
 }
 
 func (h *tracerHandler) checkStat(ctx *ptracer.Context, addr uint) ptracer.TraceAction {
 	fn := h.getString(ctx, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("check stat proc policy: ", fn)
+		h.logPath(ctx, "check stat proc policy", fn)
 		return action
 	}
-	h.Debug("check stat: ", fn)
+	h.logPath(ctx, "check stat", fn)
 	return h.Handler.CheckStat(fn)
 }
 
 func (h *tracerHandler) checkStatAt(ctx *ptracer.Context, dirfd int, addr uint) ptracer.TraceAction {
 	fn := h.getStringAt(ctx, dirfd, addr)
 	if blocked, action := h.checkProcPath(ctx.Pid, fn); blocked {
-		h.Debug("check stat proc policy: ", fn, "dirfd:", dirfd)
+		h.logPath(ctx, "check stat proc policy", fn, "dirfd", dirfd)
 		return action
 	}
-	h.Debug("check stat: ", fn, "dirfd:", dirfd)
+	h.logPath(ctx, "check stat", fn, "dirfd", dirfd)
 	return h.Handler.CheckStat(fn)
 }
 
 func (h *tracerHandler) Handle(ctx *ptracer.Context) ptracer.TraceAction {
 	syscallNo := ctx.SyscallNo()
+	h.paths, h.attrs = h.paths[:0], nil
+	// compat syscalls (e.g. int 0x80 on amd64) use a different syscall table
+	if !ctx.IsNativeArch() {
+		h.logger().Debug("compat syscall", "pid", ctx.Pid, "syscall", syscallNo,
+			"arch", fmt.Sprintf("%#x", ctx.Arch()))
+		h.record(ctx, "", ptracer.TraceKill)
+		return ptracer.TraceKill
+	}
 	syscallName, err := libseccomp.ToSyscallName(syscallNo)
-	h.Debug("syscall:", syscallNo, syscallName, err)
 	if err != nil {
-		h.Debug("invalid syscall no")
+		h.logger().Debug("invalid syscall", "pid", ctx.Pid, "syscall", syscallNo, "err", err)
+		h.record(ctx, "", ptracer.TraceKill)
 		return ptracer.TraceKill
 	}
 
@@ -221,17 +274,92 @@ func (h *tracerHandler) Handle(ctx *ptracer.Context) ptracer.TraceAction {
 		}
 	}
 
+	h.logger().Debug("syscall", "pid", ctx.Pid, "syscall", syscallName, "action", action)
+	if action != ptracer.TraceAllow && action != ptracer.TraceBan {
+		action = ptracer.TraceKill
+	}
+	if h.Audit && action != ptracer.TraceAllow {
+		action = h.audit(ctx, syscallName, action)
+	}
+	h.record(ctx, syscallName, action)
 	switch action {
 	case ptracer.TraceAllow:
 		return ptracer.TraceAllow
 	case ptracer.TraceBan:
-		h.Debug("<soft ban syscall>")
 		return softBanSyscall(ctx)
 	default:
 		return ptracer.TraceKill
 	}
 }
 
+// audit records the violation and returns the action taken in audit mode
+func (h *tracerHandler) audit(ctx *ptracer.Context, name string, action ptracer.TraceAction) ptracer.TraceAction {
+	paths := h.paths
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	for _, p := range paths {
+		h.addViolation(ctx.Pid, name, p, action)
+	}
+	h.logger().Debug("audit violation", "pid", ctx.Pid, "syscall", name, "paths", h.paths, "action", action)
+	if action == ptracer.TraceBan || h.AuditBan {
+		return ptracer.TraceBan
+	}
+	return ptracer.TraceAllow
+}
+
+// addViolation adds the violation or counts it if already recorded
+func (h *tracerHandler) addViolation(pid int, name, path string, action ptracer.TraceAction) {
+	key := violationKey{syscall: name, path: path, action: action}
+	if i, ok := h.violationIndex[key]; ok {
+		h.violations[i].Count++
+		return
+	}
+	if h.violationIndex == nil {
+		h.violationIndex = make(map[violationKey]int)
+	}
+	h.violationIndex[key] = len(h.violations)
+	h.violations = append(h.violations, runner.Violation{
+		Pid:     pid,
+		Syscall: name,
+		Path:    path,
+		Action:  action.String(),
+		Count:   1,
+	})
+}
+
+// HandleSignal implements ptracer.SignalHandler by the SignalHandler of Handler
+func (h *tracerHandler) HandleSignal(pid int, sig syscall.Signal) ptracer.TraceAction {
+	sh, ok := h.Handler.(SignalHandler)
+	if !ok {
+		return ptracer.TraceAllow
+	}
+	action := sh.CheckSignal(pid, sig)
+	h.logger().Debug("signal", "pid", pid, "signal", sig, "action", action)
+	return action
+}
+
+// record sends the traced syscall to the Recorder
+func (h *tracerHandler) record(ctx *ptracer.Context, name string, action ptracer.TraceAction) {
+	if h.Recorder == nil {
+		return
+	}
+	var paths []string
+	if len(h.paths) > 0 {
+		paths = append(paths, h.paths...)
+	}
+	h.Recorder.Record(Event{
+		Time:    time.Now(),
+		Pid:     ctx.Pid,
+		Syscall: name,
+		Nr:      ctx.SyscallNo(),
+		Args:    [6]uint{ctx.Arg0(), ctx.Arg1(), ctx.Arg2(), ctx.Arg3(), ctx.Arg4(), ctx.Arg5()},
+		Paths:   paths,
+		Attrs:   h.attrs,
+		Action:  action,
+	})
+}
+
 func softBanSyscall(ctx *ptracer.Context) ptracer.TraceAction {
 	ctx.SetReturnValue(-int(BanRet))
 	return ptracer.TraceBan
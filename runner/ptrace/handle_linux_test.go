@@ -1,10 +1,12 @@
 package ptrace
 
 import (
+	"reflect"
 	"syscall"
 	"testing"
 
 	"github.com/criyle/go-sandbox/ptracer"
+	"github.com/criyle/go-sandbox/runner"
 )
 
 type mockHandler struct {
@@ -205,3 +207,32 @@ func TestIsOpenReadOnly(t *testing.T) {
 		})
 	}
 }
+
+func TestAudit(t *testing.T) {
+	ctx := &ptracer.Context{Pid: 1234}
+
+	h := &tracerHandler{Handler: mockHandler{}, Audit: true}
+	h.paths = []string{"/etc/passwd"}
+	if act := h.audit(ctx, "openat", ptracer.TraceKill); act != ptracer.TraceAllow {
+		t.Fatalf("expected violation to be allowed, got %v", act)
+	}
+	if act := h.audit(ctx, "openat", ptracer.TraceKill); act != ptracer.TraceAllow {
+		t.Fatalf("expected violation to be allowed, got %v", act)
+	}
+	h.paths = nil
+	if act := h.audit(ctx, "socket", ptracer.TraceBan); act != ptracer.TraceBan {
+		t.Fatalf("expected soft ban to be kept, got %v", act)
+	}
+	want := []runner.Violation{
+		{Pid: 1234, Syscall: "openat", Path: "/etc/passwd", Action: "kill", Count: 2},
+		{Pid: 1234, Syscall: "socket", Action: "ban", Count: 1},
+	}
+	if !reflect.DeepEqual(h.violations, want) {
+		t.Fatalf("expected violations %v, got %v", want, h.violations)
+	}
+
+	h = &tracerHandler{Handler: mockHandler{}, Audit: true, AuditBan: true}
+	if act := h.audit(ctx, "openat", ptracer.TraceKill); act != ptracer.TraceBan {
+		t.Fatalf("expected violation to be soft banned, got %v", act)
+	}
+}
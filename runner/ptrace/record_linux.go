@@ -0,0 +1,157 @@
+package ptrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/criyle/go-sandbox/ptracer"
+)
+
+// Event is a traced syscall captured by the Recorder
+type Event struct {
+	Time    time.Time           `json:"time"`
+	Pid     int                 `json:"pid"`
+	Syscall string              `json:"syscall"`
+	Nr      uint                `json:"nr"`
+	Args    [6]uint             `json:"args"`
+	Paths   []string            `json:"paths,omitempty"`
+	Attrs   map[string]string   `json:"attrs,omitempty"` // decoded arguments (e.g. mode, dirfd)
+	Action  ptracer.TraceAction `json:"action"`
+}
+
+// Recorder receives every traced syscall of the ptrace runner
+type Recorder interface {
+	Record(Event)
+}
+
+// Recorders records events to all the recorders
+type Recorders []Recorder
+
+// Record implements Recorder
+func (r Recorders) Record(e Event) {
+	for _, rc := range r {
+		rc.Record(e)
+	}
+}
+
+// JSONRecorder writes the events as JSON lines
+type JSONRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error // first error from the writer
+}
+
+// NewJSONRecorder creates a JSONRecorder writes to w
+func NewJSONRecorder(w io.Writer) *JSONRecorder {
+	return &JSONRecorder{enc: json.NewEncoder(w)}
+}
+
+// Record implements Recorder. Events after the first write error are dropped
+func (r *JSONRecorder) Record(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(e)
+}
+
+// Err returns the first error encountered while writing the events
+func (r *JSONRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// SummaryKey groups the events in Summary
+type SummaryKey struct {
+	Syscall string
+	Path    string
+	Action  ptracer.TraceAction
+}
+
+// Summary counts the events by syscall, path and action, and keeps the
+// events that were not allowed
+type Summary struct {
+	mu      sync.Mutex
+	Counts  map[SummaryKey]int
+	Blocked []Event
+}
+
+// Record implements Recorder
+func (s *Summary) Record(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Counts == nil {
+		s.Counts = make(map[SummaryKey]int)
+	}
+	paths := e.Paths
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	for _, p := range paths {
+		s.Counts[SummaryKey{Syscall: e.Syscall, Path: p, Action: e.Action}]++
+	}
+	if e.Action != ptracer.TraceAllow {
+		s.Blocked = append(s.Blocked, e)
+	}
+}
+
+// WriteTo writes the counts as a table ordered by count
+func (s *Summary) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]SummaryKey, 0, len(s.Counts))
+	for k := range s.Counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ci, cj := s.Counts[keys[i]], s.Counts[keys[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		if keys[i].Syscall != keys[j].Syscall {
+			return keys[i].Syscall < keys[j].Syscall
+		}
+		return keys[i].Path < keys[j].Path
+	})
+
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNT\tSYSCALL\tACTION\tPATH")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Counts[k], k.Syscall, k.Action, k.Path)
+	}
+	err := tw.Flush()
+	return cw.n, err
+}
+
+// String formats the event as a strace-like line
+func (e Event) String() string {
+	name := e.Syscall
+	if name == "" {
+		name = fmt.Sprintf("syscall_%d", e.Nr)
+	}
+	s := fmt.Sprintf("[pid %d] %s(%#x, %#x, %#x, %#x, %#x, %#x)", e.Pid, name,
+		e.Args[0], e.Args[1], e.Args[2], e.Args[3], e.Args[4], e.Args[5])
+	for _, p := range e.Paths {
+		s += fmt.Sprintf(" %q", p)
+	}
+	return s + " = " + e.Action.String()
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,87 @@
+package ptrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/criyle/go-sandbox/ptracer"
+)
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	events := []Event{
+		{Pid: 1, Syscall: "openat", Paths: []string{"/etc/ld.so.cache"}, Action: ptracer.TraceAllow},
+		{Pid: 1, Syscall: "openat", Paths: []string{"/etc/ld.so.cache"}, Action: ptracer.TraceAllow},
+		{Pid: 1, Syscall: "openat", Paths: []string{"/etc/passwd"}, Action: ptracer.TraceBan},
+		{Pid: 1, Syscall: "socket", Action: ptracer.TraceKill},
+	}
+	for _, e := range events {
+		s.Record(e)
+	}
+	if c := s.Counts[SummaryKey{Syscall: "openat", Path: "/etc/ld.so.cache", Action: ptracer.TraceAllow}]; c != 2 {
+		t.Errorf("expected count 2, got %d", c)
+	}
+	if len(s.Blocked) != 2 || s.Blocked[1].Syscall != "socket" {
+		t.Errorf("expected blocked openat and socket, got %v", s.Blocked)
+	}
+
+	var buf bytes.Buffer
+	n, err := s.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("expected %d bytes written, got %d", buf.Len(), n)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "2 ") || !strings.Contains(lines[1], "/etc/ld.so.cache") {
+		t.Errorf("unexpected summary:\n%s", buf.String())
+	}
+}
+
+func TestJSONRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONRecorder(&buf)
+	r.Record(Event{Pid: 2, Syscall: "openat", Nr: 257, Paths: []string{"/a"}, Action: ptracer.TraceBan})
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["syscall"] != "openat" || m["action"] != "ban" {
+		t.Errorf("unexpected event %s", buf.String())
+	}
+}
+
+type errWriter struct{ n int }
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	w.n++
+	return 0, errors.New("write failed")
+}
+
+func TestJSONRecorderErr(t *testing.T) {
+	w := &errWriter{}
+	r := NewJSONRecorder(w)
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	r.Record(Event{Pid: 2, Syscall: "openat"})
+	r.Record(Event{Pid: 2, Syscall: "close"})
+	if err := r.Err(); err == nil || err.Error() != "write failed" {
+		t.Errorf("expected the write error, got %v", err)
+	}
+	if w.n != 1 {
+		t.Errorf("expected events dropped after the error, got %d writes", w.n)
+	}
+}
+
+func TestEventString(t *testing.T) {
+	e := Event{Pid: 3, Nr: 41, Args: [6]uint{2, 1}, Action: ptracer.TraceKill}
+	if s := e.String(); s != "[pid 3] syscall_41(0x2, 0x1, 0x0, 0x0, 0x0, 0x0) = kill" {
+		t.Errorf("unexpected %q", s)
+	}
+}
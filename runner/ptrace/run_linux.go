@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/criyle/go-sandbox/pkg/forkexec"
+	"github.com/criyle/go-sandbox/pkg/rlimit"
 	"github.com/criyle/go-sandbox/ptracer"
 	"github.com/criyle/go-sandbox/runner"
 )
@@ -15,26 +16,46 @@ func (r *Runner) Run(c context.Context) runner.Result {
 		Args:     r.Args,
 		Env:      r.Env,
 		ExecFile: r.ExecFile,
-		RLimits:  r.RLimits,
+		RLimits:  rlimit.ApplyLimit(r.RLimits, r.Limit),
 		Files:    r.Files,
 		WorkDir:  r.WorkDir,
 		Seccomp:  r.Seccomp.SockFprog(),
 		Ptrace:   true,
 		SyncFunc: r.SyncFunc,
 
+		PtraceSeize:   true,
+		PtraceOptions: ptracer.PtraceOptions,
+
 		UnshareCgroupAfterSync: os.Getuid() == 0,
 	}
 
+	logger := runner.Logger(r.Logger, r.ShowDetails)
 	th := &tracerHandler{
 		ShowDetails: r.ShowDetails,
 		Unsafe:      r.Unsafe,
 		Handler:     r.Handler,
+		Logger:      logger,
+		Recorder:    r.Recorder,
+		Audit:       r.Audit,
+		AuditBan:    r.AuditBan,
 	}
 
 	tracer := ptracer.Tracer{
 		Handler: th,
 		Runner:  ch,
 		Limit:   r.Limit,
+		Sampler: r.Sampler,
+		Logger:  logger,
+
+		MaxProcesses: r.MaxProcesses,
+		MaxThreads:   r.MaxThreads,
+	}
+	var result runner.Result
+	if r.Scheduler != nil {
+		result = r.Scheduler.Trace(c, &tracer)
+	} else {
+		result = tracer.Trace(c)
 	}
-	return tracer.Trace(c)
+	result.Violations = th.violations
+	return result
 }
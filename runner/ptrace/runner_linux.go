@@ -1,6 +1,7 @@
 package ptrace
 
 import (
+	"log/slog"
 	"syscall"
 
 	"github.com/criyle/go-sandbox/pkg/rlimit"
@@ -29,6 +30,18 @@ type Runner struct {
 	// Res limit enforced by tracer
 	Limit runner.Limit
 
+	// Sampler enforces Limit during the run and records the usage (optional)
+	Sampler *runner.Sampler
+
+	// Limits the total processes created and the concurrent threads, enforced
+	// by tracer at fork / clone events together with Limit.ProcessLimit
+	// (0 for unlimited)
+	MaxProcesses, MaxThreads uint64
+
+	// Scheduler traces the program on its pool of tracer threads instead of
+	// locking a new OS thread for the run (optional)
+	Scheduler *ptracer.Scheduler
+
 	// Defines seccomp filter for the ptrace runner
 	// file access syscalls need to set as ActionTrace
 	// allowed need to set as ActionAllow
@@ -41,6 +54,18 @@ type Runner struct {
 	// ShowDetails / Unsafe debug flag
 	ShowDetails, Unsafe bool
 
+	// Logger receives structured logs of traced syscalls and accessed paths
+	// (default: text logs to stderr if ShowDetails is set)
+	Logger *slog.Logger
+
+	// Recorder captures every traced syscall with its paths and action (optional)
+	Recorder Recorder
+
+	// Audit records the policy violations of Handler into Result.Violations
+	// instead of enforcing them. Violations are allowed, or soft banned with
+	// AuditBan, so that a policy could be dry-run without ending the program
+	Audit, AuditBan bool
+
 	// Use by cgroup to add proc
 	SyncFunc func(pid int) error
 }
@@ -55,3 +80,10 @@ type Handler interface {
 	CheckStat(string) ptracer.TraceAction
 	CheckSyscall(string) ptracer.TraceAction
 }
+
+// SignalHandler is optionally implemented by the Handler to forward
+// (TraceAllow), suppress (TraceBan) or kill (TraceKill) on signals delivered
+// to the traced processes
+type SignalHandler interface {
+	CheckSignal(pid int, sig syscall.Signal) ptracer.TraceAction
+}
@@ -7,19 +7,44 @@ import (
 
 // Result is the program runner result
 type Result struct {
-	Status            // result status
-	ExitStatus int    // exit status (signal number if signalled)
-	Error      string // potential detailed error message (for program runner error)
+	Status             // result status
+	Cause       Cause  // terminating cause (e.g. which limit was exceeded)
+	ExitStatus  int    // exit status (signal number if signalled)
+	ChildSignal int    // first fatal signal (e.g. SIGSEGV) that terminated a child process (ptrace runner)
+	Error       string // potential detailed error message (for program runner error)
 
 	Time     time.Duration // used user CPU time  (underlying type int64 in ns)
 	Memory   Size          // used user memory    (underlying type uint64 in bytes)
 	ProcPeak uint64        // maximum processes
 
+	// cpu throttling of the cgroup, reported by runners with cgroup cpu controller
+	Throttled   time.Duration // total time the cgroup was throttled
+	NrThrottled uint64        // number of periods the cgroup was throttled
+
+	// pressure stall (PSI some) of the cgroup during the run, reported with
+	// cgroup v2 to tell the runs slowed down by contention
+	CPUStall, MemoryStall, IOStall time.Duration
+
+	// time series of resource usage recorded by the Sampler
+	Samples []Sample
+
+	// policy violations recorded instead of enforced in audit mode
+	Violations []Violation
+
 	// metrics for the program runner
 	SetUpTime   time.Duration
 	RunningTime time.Duration
 }
 
+// Violation is a policy violation of the traced program recorded in audit mode
+type Violation struct {
+	Pid     int    // pid of the first occurrence
+	Syscall string // syscall name
+	Path    string // accessed path for file access syscalls
+	Action  string // action of the enforcing policy (e.g. ban / kill)
+	Count   int    // number of occurrences
+}
+
 func (r Result) String() string {
 	switch r.Status {
 	case StatusNormal:
@@ -32,6 +57,9 @@ func (r Result) String() string {
 		return fmt.Sprintf("Result[RunnerFailed(%s)][%v %v][%v %v]", r.Error, r.Time, r.Memory, r.SetUpTime, r.RunningTime)
 
 	default:
+		if r.Cause != CauseNone {
+			return fmt.Sprintf("Result[%v(%v %s %d)][%v %v][%v %v]", r.Status, r.Cause, r.Error, r.ExitStatus, r.Time, r.Memory, r.SetUpTime, r.RunningTime)
+		}
 		return fmt.Sprintf("Result[%v(%s %d)][%v %v][%v %v]", r.Status, r.Error, r.ExitStatus, r.Time, r.Memory, r.SetUpTime, r.RunningTime)
 	}
 }
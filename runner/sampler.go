@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultSampleInterval = 10 * time.Millisecond
+
+// UsageFunc reads the CPU time and memory usage of the running program
+type UsageFunc func(pid int) (time.Duration, Size, error)
+
+// Sample is the resource usage of the program at a point of its run
+type Sample struct {
+	Elapsed time.Duration // wall clock time since the sampling started
+	Time    time.Duration // CPU time used
+	Memory  Size          // memory used
+}
+
+// Sampler polls the resource usage of a running program at a fixed interval
+// so that limits are enforced even if the program sleeps or blocks and never
+// returns to the runner
+type Sampler struct {
+	// Interval is the sampling interval (default: 10ms)
+	Interval time.Duration
+
+	// Usage reads the resource usage, e.g. from the cgroup of the program
+	// (default: ProcUsage, /proc/<pid>/stat on linux)
+	Usage UsageFunc
+
+	// MaxSamples is the maximum number of samples kept in the time series.
+	// Samples are merged by taking every other sample when it is full.
+	// Zero disables recording
+	MaxSamples int
+}
+
+// Sampling is a running sampler started by Sampler.Start
+type Sampling struct {
+	*Sampler
+	pid   int
+	limit Limit
+	kill  func()
+
+	done    chan struct{}
+	stopped chan struct{}
+
+	mu      sync.Mutex
+	status  Status
+	samples []Sample
+	stride  int // keep one of stride samples
+	skipped int
+}
+
+// Start starts to sample the program with pid. kill is called once if the
+// usage exceeds the limit
+func (s *Sampler) Start(pid int, limit Limit, kill func()) *Sampling {
+	sp := &Sampling{
+		Sampler: s,
+		pid:     pid,
+		limit:   limit,
+		kill:    kill,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		status:  StatusNormal,
+		stride:  1,
+	}
+	go sp.loop()
+	return sp
+}
+
+// Stop stops the sampling and returns the status if a limit was exceeded
+// (StatusNormal otherwise) together with the recorded time series
+func (s *Sampling) Stop() (Status, []Sample) {
+	close(s.done)
+	<-s.stopped
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status, s.samples
+}
+
+// Finish stops the sampling and attaches the time series to the result. The
+// status and cause are overridden if the sampler killed the program
+func (s *Sampling) Finish(r *Result) {
+	var status Status
+	status, r.Samples = s.Stop()
+	if status != StatusNormal && r.Status != StatusRunnerError {
+		r.Status = status
+		r.Cause = LimitCause(status)
+	}
+}
+
+func (s *Sampling) loop() {
+	defer close(s.stopped)
+
+	interval := s.Interval
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	usage := s.Usage
+	if usage == nil {
+		usage = ProcUsage
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		t, m, err := usage(s.pid)
+		if err != nil {
+			// the program may have exited
+			continue
+		}
+		if s.sample(Sample{Elapsed: time.Since(start), Time: t, Memory: m}) {
+			s.kill()
+			return
+		}
+	}
+}
+
+// sample records the sample and returns true if the limit was exceeded
+func (s *Sampling) sample(sa Sample) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.MaxSamples > 0 {
+		if s.skipped++; s.skipped >= s.stride {
+			s.skipped = 0
+			s.samples = append(s.samples, sa)
+		}
+		if len(s.samples) >= s.MaxSamples {
+			s.samples = compact(s.samples)
+			s.stride *= 2
+		}
+	}
+
+	switch {
+	case s.limit.TimeLimit > 0 && sa.Time > s.limit.TimeLimit:
+		s.status = StatusTimeLimitExceeded
+	case s.limit.MemoryLimit > 0 && sa.Memory > s.limit.MemoryLimit:
+		s.status = StatusMemoryLimitExceeded
+	default:
+		return false
+	}
+	// keep the sample that exceeded the limit
+	if n := len(s.samples); s.MaxSamples > 0 && (n == 0 || s.samples[n-1] != sa) {
+		s.samples = append(s.samples, sa)
+	}
+	return true
+}
+
+// compact keeps every other sample in place
+func compact(s []Sample) []Sample {
+	n := 0
+	for i := 1; i < len(s); i += 2 {
+		s[n] = s[i]
+		n++
+	}
+	return s[:n]
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSamplerEnforceLimit(t *testing.T) {
+	var n time.Duration
+	s := &Sampler{
+		Interval: time.Millisecond,
+		Usage: func(int) (time.Duration, Size, error) {
+			n += time.Millisecond
+			return n, 1 << 20, nil
+		},
+		MaxSamples: 8,
+	}
+	killed := make(chan struct{})
+	sp := s.Start(0, Limit{TimeLimit: 100 * time.Millisecond, MemoryLimit: 2 << 20}, func() {
+		close(killed)
+	})
+	select {
+	case <-killed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected sampler to kill the program")
+	}
+	status, samples := sp.Stop()
+	if status != StatusTimeLimitExceeded {
+		t.Errorf("expected %v, got %v", StatusTimeLimitExceeded, status)
+	}
+	if len(samples) == 0 || len(samples) > s.MaxSamples {
+		t.Fatalf("expected at most %d samples, got %d", s.MaxSamples, len(samples))
+	}
+	if last := samples[len(samples)-1]; last.Time <= 100*time.Millisecond {
+		t.Errorf("expected last sample to exceed the limit, got %v", last.Time)
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Elapsed < samples[i-1].Elapsed {
+			t.Errorf("expected samples in order, got %v", samples)
+		}
+	}
+}
+
+func TestSamplerStop(t *testing.T) {
+	s := &Sampler{
+		Usage: func(int) (time.Duration, Size, error) {
+			return 0, 0, nil
+		},
+	}
+	sp := s.Start(0, Limit{TimeLimit: time.Second, MemoryLimit: 1 << 20}, func() {
+		t.Error("unexpected kill")
+	})
+	time.Sleep(50 * time.Millisecond)
+	status, samples := sp.Stop()
+	if status != StatusNormal {
+		t.Errorf("expected %v, got %v", StatusNormal, status)
+	}
+	if samples != nil {
+		t.Errorf("expected no samples recorded, got %d", len(samples))
+	}
+}
+
+func TestSamplingFinish(t *testing.T) {
+	s := &Sampler{
+		Interval: time.Millisecond,
+		Usage: func(int) (time.Duration, Size, error) {
+			return 0, 2 << 20, nil
+		},
+	}
+	killed := make(chan struct{})
+	sp := s.Start(0, Limit{TimeLimit: time.Second, MemoryLimit: 1 << 20}, func() {
+		close(killed)
+	})
+	<-killed
+	r := Result{Status: StatusTimeLimitExceeded, Cause: CauseKilled}
+	sp.Finish(&r)
+	if r.Status != StatusMemoryLimitExceeded || r.Cause != CauseMemoryLimit {
+		t.Errorf("expected %v(%v), got %v(%v)", StatusMemoryLimitExceeded, CauseMemoryLimit, r.Status, r.Cause)
+	}
+}
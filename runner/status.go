@@ -23,6 +23,10 @@ const (
 
 	// Programmer Runner Error
 	StatusRunnerError // 8 runner error
+
+	// Resource Limit Exceeded
+	StatusRealTimeLimitExceeded // 9 real time limit exceeded (idle)
+	StatusProcessLimitExceeded  // 10 process limit exceeded
 )
 
 var (
@@ -36,6 +40,8 @@ var (
 		"Signalled",
 		"Nonzero Exit Status",
 		"Runner Error",
+		"Real Time Limit Exceeded",
+		"Process Limit Exceeded",
 	}
 )
 
@@ -2,13 +2,12 @@ package unshare
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"time"
 
 	"golang.org/x/sys/unix"
 
 	"github.com/criyle/go-sandbox/pkg/forkexec"
+	"github.com/criyle/go-sandbox/pkg/rlimit"
 	"github.com/criyle/go-sandbox/runner"
 )
 
@@ -19,11 +18,17 @@ const (
 
 // Run starts the unshared process
 func (r *Runner) Run(c context.Context) (result runner.Result) {
+	if r.Limit.ProcessLimit > 0 {
+		result.Status = runner.StatusRunnerError
+		result.Error = "process limit is not supported, use cgroup pids.max"
+		return
+	}
+
 	ch := &forkexec.Runner{
 		Args:       r.Args,
 		Env:        r.Env,
 		ExecFile:   r.ExecFile,
-		RLimits:    r.RLimits,
+		RLimits:    rlimit.ApplyLimit(r.RLimits, r.Limit),
 		Files:      r.Files,
 		WorkDir:    r.WorkDir,
 		Seccomp:    r.Seccomp.SockFprog(),
@@ -48,15 +53,16 @@ func (r *Runner) Run(c context.Context) (result runner.Result) {
 	)
 
 	// Start the runner
+	log := runner.Logger(r.Logger, r.ShowDetails)
 	pgid, err := ch.Start()
-	r.println("Starts: ", pgid, err)
 	if err != nil {
+		log.Debug("start failed", "err", err)
 		result.Status = runner.StatusRunnerError
 		result.Error = err.Error()
 		return
 	}
 
-	ctx, cancel := context.WithCancel(c)
+	ctx, cancel := r.Limit.WithWallTimeLimit(c)
 	defer cancel()
 
 	// handle cancel
@@ -65,21 +71,33 @@ func (r *Runner) Run(c context.Context) (result runner.Result) {
 		killAll(pgid)
 	}()
 
+	fTime = time.Now()
+	log.Debug("started", "pid", pgid, "elapsed", fTime.Sub(sTime))
+	var sampling *runner.Sampling
+	if r.Sampler != nil {
+		sampling = r.Sampler.Start(pgid, r.Limit, func() { killAll(pgid) })
+	}
+
 	// kill all tracee upon return
 	defer func() {
 		killAll(pgid)
 		collectZombie(pgid)
+		if sampling != nil {
+			sampling.Finish(&result)
+		}
+		r.Limit.CheckContext(ctx, &result)
 		result.SetUpTime = fTime.Sub(sTime)
 		result.RunningTime = time.Since(fTime)
+		log.Debug("finished", "pid", pgid, "status", result.Status, "cause", result.Cause,
+			"elapsed", result.RunningTime)
 	}()
 
-	fTime = time.Now()
 	for {
 		_, err := unix.Wait4(pgid, &wstatus, 0, &rusage)
 		if err == unix.EINTR {
 			continue
 		}
-		r.println("wait4: ", wstatus)
+		log.Debug("wait4", "pid", pgid, "status", wstatus, "err", err)
 		if err != nil {
 			result.Status = runner.StatusRunnerError
 			result.Error = err.Error()
@@ -99,6 +117,7 @@ func (r *Runner) Run(c context.Context) (result runner.Result) {
 		}
 		result = runner.Result{
 			Status: status,
+			Cause:  runner.LimitCause(status),
 			Time:   userTime,
 			Memory: userMem,
 		}
@@ -117,17 +136,12 @@ func (r *Runner) Run(c context.Context) (result runner.Result) {
 
 		case wstatus.Signaled():
 			sig := wstatus.Signal()
-			switch sig {
-			case unix.SIGXCPU, unix.SIGKILL:
-				status = runner.StatusTimeLimitExceeded
-			case unix.SIGXFSZ:
-				status = runner.StatusOutputLimitExceeded
-			case unix.SIGSYS:
-				status = runner.StatusDisallowedSyscall
-			default:
-				status = runner.StatusSignalled
+			result.Status, result.Cause = runner.SignalStatus(sig)
+			// killed by the kernel on hard cpu limit
+			cpuTime := time.Duration(rusage.Utime.Nano() + rusage.Stime.Nano())
+			if sig == unix.SIGKILL && rlimit.CPUHardLimitExceeded(ch.RLimits, cpuTime) {
+				result.Cause = runner.CauseCPUTimeLimit
 			}
-			result.Status = status
 			result.ExitStatus = int(sig)
 			return
 		}
@@ -152,9 +166,3 @@ func collectZombie(pgid int) {
 		}
 	}
 }
-
-func (r *Runner) println(v ...interface{}) {
-	if r.ShowDetails {
-		fmt.Fprintln(os.Stderr, v...)
-	}
-}
@@ -1,6 +1,8 @@
 package unshare
 
 import (
+	"log/slog"
+
 	"github.com/criyle/go-sandbox/pkg/mount"
 	"github.com/criyle/go-sandbox/pkg/rlimit"
 	"github.com/criyle/go-sandbox/pkg/seccomp"
@@ -25,9 +27,14 @@ type Runner struct {
 	// Resource limit set by set rlimit
 	RLimits []rlimit.RLimit
 
-	// Resource limit enforced by tracer
+	// Resource limit enforced by runner. ProcessLimit is not supported and
+	// results in StatusRunnerError, set cgroup pids.max instead since
+	// RLIMIT_NPROC counts all processes of the user and is ignored for root
 	Limit runner.Limit
 
+	// Sampler enforces Limit during the run and records the usage (optional)
+	Sampler *runner.Sampler
+
 	// Seccomp defines the seccomp filter attach to the process (should be whitelist only)
 	Seccomp seccomp.Filter
 
@@ -43,6 +50,10 @@ type Runner struct {
 	// Show Details
 	ShowDetails bool
 
+	// Logger receives structured logs of the run (default: text logs to
+	// stderr if ShowDetails is set)
+	Logger *slog.Logger
+
 	// Use by cgroup to add proc
 	SyncFunc func(pid int) error
 }
@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// USER_HZ is 100 on all linux platforms
+	userHZ = 100
+
+	// fields of /proc/<pid>/stat after the command name (field 3)
+	statUTime  = 14 - 3
+	statCUTime = 16 - 3
+	statRSS    = 24 - 3
+)
+
+var pageSize = Size(os.Getpagesize())
+
+// ProcUsage reads user CPU time and resident memory of the process tree
+// rooted at pid from /proc/<pid>/stat. The CPU time includes the children
+// that were waited for, and the memory is the sum of the live processes
+func ProcUsage(pid int) (time.Duration, Size, error) {
+	utime, rss, err := procStat(pid)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, c := range procChildren(pid) {
+		t, m, err := ProcUsage(c)
+		if err != nil {
+			// the child may have exited
+			continue
+		}
+		utime += t
+		rss += m
+	}
+	return utime, rss, nil
+}
+
+// procStat reads the user CPU time (including waited children) and resident
+// memory of the single process
+func procStat(pid int) (time.Duration, Size, error) {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	// command name is in parentheses and may contain spaces
+	i := bytes.LastIndexByte(b, ')')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("proc stat: invalid format")
+	}
+	f := bytes.Fields(b[i+1:])
+	if len(f) <= statRSS {
+		return 0, 0, fmt.Errorf("proc stat: too few fields")
+	}
+	utime, err := strconv.ParseUint(string(f[statUTime]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("proc stat: utime: %w", err)
+	}
+	cutime, err := strconv.ParseUint(string(f[statCUTime]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("proc stat: cutime: %w", err)
+	}
+	rss, err := strconv.ParseUint(string(f[statRSS]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("proc stat: rss: %w", err)
+	}
+	return time.Duration(utime+cutime) * time.Second / userHZ, Size(rss) * pageSize, nil
+}
+
+// procChildren reads the child processes created by all threads of the
+// process from /proc/<pid>/task/<tid>/children (CONFIG_PROC_CHILDREN)
+func procChildren(pid int) []int {
+	dir := "/proc/" + strconv.Itoa(pid) + "/task/"
+	tasks, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var children []int
+	for _, t := range tasks {
+		b, err := os.ReadFile(dir + t.Name() + "/children")
+		if err != nil {
+			continue
+		}
+		for _, f := range bytes.Fields(b) {
+			if c, err := strconv.Atoi(string(f)); err == nil {
+				children = append(children, c)
+			}
+		}
+	}
+	return children
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestProcUsage(t *testing.T) {
+	_, m, err := ProcUsage(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == 0 {
+		t.Errorf("expected non-zero memory usage")
+	}
+}
+
+func TestProcUsageTree(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "/bin/sleep 10 & /bin/sleep 10 & wait")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(procChildren(pid)) < 2 {
+		if _, err := os.Stat("/proc/self/task/" + strconv.Itoa(os.Getpid()) + "/children"); err != nil {
+			t.Skip("children of process not available:", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("children not started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_, self, err := procStat(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tree, err := ProcUsage(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree <= self {
+		t.Errorf("expected memory of the tree (%v) larger than the process (%v)", tree, self)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"time"
+)
+
+// ProcUsage is not supported on non-linux platforms
+func ProcUsage(pid int) (time.Duration, Size, error) {
+	return 0, 0, errors.ErrUnsupported
+}